@@ -2,44 +2,164 @@ package main
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/sriram651/go-scheduler/internal/app"
 	"github.com/sriram651/go-scheduler/internal/config"
+	"github.com/sriram651/go-scheduler/internal/db"
+	"github.com/sriram651/go-scheduler/internal/logging"
+	"github.com/sriram651/go-scheduler/internal/quote"
+	"github.com/sriram651/go-scheduler/internal/tracing"
 )
 
+// How long main waits for the app to stop after a signal before closing the database anyway.
+// Kept under kill_timeout in fly.toml.
+const shutdownTimeout = 25 * time.Second
+
 func main() {
-	cfg := config.LoadConfig()
+	cfg, configErr := config.LoadConfig(os.Args[1:])
+
+	if configErr != nil {
+		logging.Fatal("Invalid config", "error", configErr)
+	}
+
+	if err := logging.Setup(cfg.LogFormat, cfg.LogLevel); err != nil {
+		logging.Fatal("Invalid logging config", "error", err)
+	}
+
+	slog.Info("Config loaded", "file", cfg.ConfigFile)
+
+	// `scheduler migrate` applies the schema and exits, e.g. as a Fly release command
+	if len(cfg.Args) > 0 && cfg.Args[0] == "migrate" {
+		runMigrate(cfg)
+		return
+	}
+
+	// `scheduler quotes import <file>` loads a JSON or CSV file into the curated quote library and exits
+	if len(cfg.Args) > 0 && cfg.Args[0] == "quotes" {
+		runQuotes(cfg, cfg.Args[1:])
+		return
+	}
+
+	// Deferred first so it runs last, after the pool is closed and traces are flushed
+	exitCode := 0
+	defer func() { os.Exit(exitCode) }()
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	shutdownTracing, tracingErr := tracing.Setup(ctx, cfg.Tracing)
+
+	if tracingErr != nil {
+		logging.Fatal("Could not set up tracing", "error", tracingErr)
+	}
+
+	// Runs last, so spans from the shutdown itself are flushed too
+	defer func() {
+		flushCtx, flushCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer flushCancel()
+
+		if err := shutdownTracing(flushCtx); err != nil {
+			slog.Error("Error flushing traces", "error", err)
+		}
+	}()
+
 	newApp := app.New(cfg)
 
 	defer func() {
-		log.Println("❌ Closing the postgres pool...")
+		slog.Info("Closing the postgres pool")
 
 		err := newApp.Database.Close()
 
 		if err != nil {
-			log.Println("❌ Error closing the postgres pool:", err)
+			slog.Error("Error closing the postgres pool", "error", err)
 		}
 	}()
 
-	go newApp.Start(ctx)
+	appDone := make(chan struct{})
+
+	var appErr error
+
+	go func() {
+		defer close(appDone)
+		appErr = newApp.Start(ctx)
+	}()
+
+	waitForShutdown(cancel, appDone)
 
-	waitForShutdown(cancel)
+	// The deferred pool close and trace flush must wait for in-flight sends, the leader lock release
+	// and the webhook removal
+	select {
+	case <-appDone:
+		slog.Info("App stopped")
+
+		// A non-zero exit has Fly restart the machine, e.g. after polling got stuck
+		if appErr != nil {
+			exitCode = 1
+		}
+	case <-time.After(shutdownTimeout):
+		slog.Warn("App did not stop in time, shutting down anyway", "timeout", shutdownTimeout.String())
+	}
 }
 
-// Handle graceful shutdown
-func waitForShutdown(cancel context.CancelFunc) {
+// Handle graceful shutdown, on a signal or when the app stops by itself
+func waitForShutdown(cancel context.CancelFunc, appDone <-chan struct{}) {
 	interruptChannel := make(chan os.Signal, 1)
 	signal.Notify(interruptChannel, syscall.SIGINT, syscall.SIGTERM)
 
-	<-interruptChannel
+	select {
+	case <-interruptChannel:
+	case <-appDone:
+	}
 
 	cancel()
 }
+
+func runMigrate(cfg config.Config) {
+	// Connect applies any pending migrations before returning
+	database := db.Connect(cfg.DatabaseURL)
+
+	if err := database.Close(); err != nil {
+		slog.Error("Error closing the postgres pool", "error", err)
+	}
+}
+
+func runQuotes(cfg config.Config, args []string) {
+	if len(args) != 2 || args[0] != "import" {
+		logging.Fatal("Usage: scheduler quotes import <file.json|file.csv>")
+	}
+
+	quotes, loadErr := quote.LoadFile(args[1])
+
+	if loadErr != nil {
+		logging.Fatal("Could not read quotes", "error", loadErr)
+	}
+
+	curated := make([]db.Quote, 0, len(quotes))
+
+	for _, q := range quotes {
+		if q.Category != "" && !quote.IsValidCategory(q.Category) {
+			slog.Warn("Quote category can't be picked with /category", "quote_id", q.ID, "category", q.Category)
+		}
+
+		curated = append(curated, db.Quote{Text: q.Text, Author: q.Author, Category: q.Category})
+	}
+
+	database := db.Connect(cfg.DatabaseURL)
+
+	inserted, importErr := db.ImportCuratedQuotes(context.Background(), database, curated)
+
+	if err := database.Close(); err != nil {
+		slog.Error("Error closing the postgres pool", "error", err)
+	}
+
+	if importErr != nil {
+		logging.Fatal("Could not import quotes", "error", importErr)
+	}
+
+	slog.Info("Imported quotes", "file", args[1], "inserted", inserted, "duplicates", len(curated)-inserted)
+}
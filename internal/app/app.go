@@ -3,7 +3,11 @@ package app
 import (
 	"context"
 	"database/sql"
-	"log"
+	"errors"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	_ "time/tzdata"
@@ -11,60 +15,236 @@ import (
 	"github.com/sriram651/go-scheduler/internal/broadcast"
 	"github.com/sriram651/go-scheduler/internal/config"
 	"github.com/sriram651/go-scheduler/internal/db"
-	"github.com/sriram651/go-scheduler/internal/quote"
+	"github.com/sriram651/go-scheduler/internal/metrics"
 	"github.com/sriram651/go-scheduler/internal/scheduler"
 	"github.com/sriram651/go-scheduler/internal/telegram"
 )
 
+// The send_hour migration 002 seeds bot_config with, used until the stored one has been read
+const defaultSendHour = 9
+
 type App struct {
 	Telegram  *telegram.Client
 	Scheduler *scheduler.Scheduler
 	Broadcast *broadcast.Broadcast
 	Database  *sql.DB
+
+	// How far back missed broadcast ticks are replayed on startup. Zero disables catch-up.
+	CatchUpWindow time.Duration
+
+	// Registered jobs running the broadcast handler, the ones catch-up replays
+	catchUpJobs []string
+
+	// Telegram update delivery: "polling" or "webhook", plus the webhook settings used in the latter
+	UpdateMode        string
+	WebhookURL        string
+	WebhookSecret     string
+	WebhookListenAddr string
+
+	// Where /metrics, /healthz and /readyz are served. Empty disables the server.
+	MetricsListenAddr string
+
+	// How often bot_config is re-read for live changes. Zero disables hot reload.
+	ConfigRefreshInterval time.Duration
+
+	// How often a standby instance retries the leader lock, and how often the leader checks it still holds it
+	LeaderRetryInterval time.Duration
+
+	// Unix nanoseconds since this instance became leader, 0 while on standby. Read by the health checks.
+	leaderSince atomic.Int64
+
+	// This instance's Fly machine, empty when not running on Fly. Webhook requests reaching a standby are replayed on the leader's.
+	machineID string
 }
 
 func New(cfg config.Config) *App {
 	databaseClient := db.Connect(cfg.DatabaseURL)
 
-	telegramClient := telegram.NewClient(cfg.TelegramBaseURL, cfg.TelegramToken, cfg.TelegramPollTimeout, databaseClient)
-	schedulerClient := scheduler.New(cfg.Schedule)
+	telegramClient := telegram.NewClient(cfg.TelegramBaseURL, cfg.TelegramToken, cfg.TelegramPollTimeout, cfg.SendTimeout, databaseClient)
+	schedulerClient := scheduler.New(databaseClient)
+
+	quoteProvider := newQuoteProvider(cfg, databaseClient)
+	broadcastClient := broadcast.NewClient(quoteProvider, telegramClient, databaseClient, cfg.DefaultQuote, cfg.BroadcastWorkers, cfg.SendRetries)
 
-	quoteClient := quote.NewClient(cfg.QuotesBaseURL, cfg.DefaultQuote)
-	broadcastClient := broadcast.NewClient(quoteClient, telegramClient, databaseClient)
+	leaderRetryInterval := cfg.LeaderRetryInterval
+
+	if leaderRetryInterval <= 0 {
+		leaderRetryInterval = 15 * time.Second
+	}
 
-	return &App{
+	newApp := &App{
 		Telegram:  telegramClient,
 		Scheduler: schedulerClient,
 		Broadcast: broadcastClient,
 		Database:  databaseClient,
+
+		CatchUpWindow:       cfg.CatchUpWindow,
+		LeaderRetryInterval: leaderRetryInterval,
+
+		ConfigRefreshInterval: cfg.ConfigRefresh,
+
+		UpdateMode:        cfg.UpdateMode,
+		WebhookURL:        cfg.WebhookURL,
+		WebhookSecret:     cfg.WebhookSecret,
+		WebhookListenAddr: cfg.WebhookListenAddr,
+
+		MetricsListenAddr: cfg.MetricsListenAddr,
+
+		// Set by Fly on every machine
+		machineID: os.Getenv("FLY_MACHINE_ID"),
+	}
+
+	// Until bot_config is read on taking leadership
+	newApp.updateSendHour(defaultSendHour)
+
+	metrics.RegisterSubscribedUsers(newApp.countSubscribedUsers)
+
+	newApp.registerJobs(context.Background(), cfg.Schedule)
+
+	telegramClient.SetAdmins(cfg.AdminChatIDs, telegram.AdminActions{
+		SetSendHour:  newApp.updateSendHour,
+		Broadcast:    broadcastClient.SendNow,
+		PreviewQuote: broadcastClient.PreviewQuote,
+	})
+
+	return newApp
+}
+
+// Start blocks until ctx is cancelled and everything it started has stopped, so the caller can close the database
+// once it returns. Only the instance holding the leader lock polls Telegram and runs the cron;
+// the others wait on standby and take over if the leader goes away.
+// It returns an error only when it stopped on its own because polling got stuck, in which case the process should exit non-zero.
+func (a *App) Start(ctx context.Context) error {
+	ctx, stop := context.WithCancelCause(ctx)
+	defer stop(nil)
+
+	var backgroundWaitGroup sync.WaitGroup
+	defer backgroundWaitGroup.Wait()
+
+	// Served on standby instances too, so every instance can be scraped
+	if a.MetricsListenAddr != "" {
+		backgroundWaitGroup.Go(func() { a.serveHTTP(ctx) })
+	}
+
+	backgroundWaitGroup.Go(func() { a.watchPolling(ctx, stop) })
+
+	// Served on standby instances too, since Fly may route an update to any machine; see forwardToLeader
+	if a.UpdateMode == "webhook" {
+		backgroundWaitGroup.Go(func() {
+			a.Telegram.ServeWebhook(ctx, a.WebhookListenAddr, a.WebhookURL, a.WebhookSecret, a.forwardToLeader)
+		})
+	}
+
+	for {
+		lock := a.waitForLeadership(ctx)
+
+		if lock == nil {
+			return stoppedErr(ctx)
+		}
+
+		leaderCtx, leaderCancel := context.WithCancel(ctx)
+
+		go a.watchLeadership(leaderCtx, lock, leaderCancel)
+
+		a.leaderSince.Store(time.Now().UnixNano())
+
+		a.runAsLeader(leaderCtx)
+
+		a.leaderSince.Store(0)
+
+		leaderCancel()
+
+		// Only on shutdown: after a lost lock, the new leader may already have registered its own webhook
+		if ctx.Err() != nil && a.UpdateMode == "webhook" {
+			a.removeWebhook(lock)
+		}
+
+		if err := lock.Release(context.Background()); err != nil {
+			slog.Warn("Error releasing the leader lock", "error", err)
+		}
+
+		if ctx.Err() != nil {
+			return stoppedErr(ctx)
+		}
+
+		slog.Warn("Leadership lost, back to standby")
+	}
+}
+
+// stoppedErr is Start's result once ctx is done: nil for a normal shutdown, the cause if the app stopped itself.
+func stoppedErr(ctx context.Context) error {
+	if cause := context.Cause(ctx); errors.Is(cause, errPollingStalled) {
+		return cause
 	}
+
+	return nil
 }
 
-func (a *App) Start(ctx context.Context) {
+// runAsLeader starts the update receiver, catch-up and the scheduler, and returns once ctx is cancelled and all of them have stopped.
+func (a *App) runAsLeader(ctx context.Context) {
 	// Before starting, fetch the stored telegram offset
 	telegramOffset, getOffsetErr := db.GetTelegramOffset(ctx, a.Database)
 	sendHour, getSendHourErr := db.GetSendHour(ctx, a.Database)
 
 	if getOffsetErr != nil {
-		log.Println("Error getting `telegram_offset` from `bot_config` table:", getOffsetErr)
+		slog.ErrorContext(ctx, "Error getting telegram_offset from bot_config", "error", getOffsetErr)
 	}
 
+	// To avoid old messages replays, we store and set the offset if the scheduler restarts for some reason.
+	a.Telegram.UpdateOffset(telegramOffset)
+
+	// Update the send_hour retrieved from the DB into the broadcast's client. A transient DB error mustn't end the
+	// process, so the hour already held (defaultSendHour at first) stays until watchBotConfig or the next term reads it.
 	if getSendHourErr != nil {
-		log.Fatalln("Error getting `send_hour` from `bot_config` table:", getSendHourErr)
+		slog.ErrorContext(ctx, "Error getting send_hour from bot_config, keeping the current one", "send_hour", a.Telegram.SendHour(), "error", getSendHourErr)
+	} else {
+		a.updateSendHour(int(sendHour))
 	}
 
-	// To avoid old messages replays, we store and set the offset if the scheduler restarts for some reason.
-	a.Telegram.UpdateOffset(telegramOffset)
+	// Wait for every service to stop, so a new leadership term never overlaps the previous one
+	var servicesWaitGroup sync.WaitGroup
 
-	// Update the send_hour retrieved from the DB into the broadcast's client
-	a.Broadcast.UpdateSendHour(int(sendHour))
-	a.Telegram.UpdateSendHour(int(sendHour))
+	// Start receiving telegram updates, through the webhook server or long polling
+	if a.UpdateMode == "webhook" {
+		a.leadWebhook(ctx)
 
-	// Start the telegram long polling
-	go a.Telegram.StartPolling(ctx)
+		servicesWaitGroup.Go(func() { a.Telegram.RegisterWebhook(ctx, a.WebhookURL, a.WebhookSecret) })
+	} else {
+		servicesWaitGroup.Go(func() { a.Telegram.StartPolling(ctx) })
+	}
+
+	// Replay broadcast ticks missed while the service was down
+	servicesWaitGroup.Go(func() { a.catchUpMissedRuns(ctx, time.Now().UTC()) })
 
-	// Start scheduler service and send in the broadcast run
-	go a.Scheduler.Start(ctx, func() { a.Broadcast.Run(ctx, time.Now().UTC()) })
+	// Pick up bot_config edits without a restart
+	servicesWaitGroup.Go(func() { a.watchBotConfig(ctx) })
+
+	// Start scheduler service with every registered job
+	servicesWaitGroup.Go(func() { a.Scheduler.Start(ctx) })
 
 	<-ctx.Done()
+
+	servicesWaitGroup.Wait()
+
+	// Webhook updates still being handled, before the admin broadcasts they may have started
+	if a.UpdateMode == "webhook" {
+		a.Telegram.StepDown()
+	}
+
+	// An admin /broadcast still finishing its in-flight sends and run history
+	a.Telegram.Wait()
+}
+
+// catchUpMissedRuns replays the ticks each broadcast job missed while no leader was running it, through the scheduler
+// so every job's own schedule, enabled flag and overlap policy apply. The daily delivery ledger keeps this from double-sending.
+func (a *App) catchUpMissedRuns(ctx context.Context, nowUTC time.Time) {
+	for _, name := range a.catchUpJobs {
+		a.Scheduler.CatchUp(ctx, name, a.CatchUpWindow, nowUTC)
+	}
+}
+
+// updateSendHour pushes the global send hour to every component that keeps a copy of it.
+func (a *App) updateSendHour(sendHour int) {
+	a.Broadcast.UpdateSendHour(sendHour)
+	a.Telegram.UpdateSendHour(sendHour)
 }
@@ -0,0 +1,162 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// How long the leader may go without a getUpdates call returning (or succeeding, for /readyz) before polling counts as stale.
+// A healthy long poll returns at least every --poll-timeout, which is capped at 2 minutes.
+const pollStaleAfter = 3 * time.Minute
+
+// errPollingStalled is what Start returns when it stopped itself because the polling loop was stuck.
+var errPollingStalled = errors.New("polling stalled")
+
+type healthReport struct {
+	Status string            `json:"status"`
+	Role   string            `json:"role"`
+	Checks map[string]string `json:"checks"`
+}
+
+// handleHealthz is the liveness check. It only fails when this instance is leader and its polling loop is stuck,
+// which a restart fixes. getUpdates failing, e.g. during a Telegram outage, is left to /readyz. Standby instances are always live.
+func (a *App) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	report := healthReport{Status: "ok", Role: a.role(), Checks: map[string]string{}}
+
+	if report.Role == "leader" {
+		if err := a.checkPollLoop(); err != "" {
+			report.Checks["polling"] = err
+			report.Status = "unhealthy"
+		} else {
+			report.Checks["polling"] = "ok"
+		}
+	}
+
+	writeHealthReport(w, report)
+}
+
+// handleReadyz is the readiness check: the database answers a ping and, on the leader, the scheduler is running
+// and polling is fresh.
+func (a *App) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	report := healthReport{Status: "ok", Role: a.role(), Checks: map[string]string{}}
+
+	fail := func(check string, reason string) {
+		report.Checks[check] = reason
+		report.Status = "unavailable"
+	}
+
+	pingCtx, pingCancel := context.WithTimeout(r.Context(), 2*time.Second)
+
+	pingErr := a.Database.PingContext(pingCtx)
+
+	pingCancel()
+
+	if pingErr != nil {
+		fail("database", pingErr.Error())
+	} else {
+		report.Checks["database"] = "ok"
+	}
+
+	if report.Role == "leader" {
+		if !a.Scheduler.Running() {
+			fail("scheduler", "not running")
+		} else {
+			report.Checks["scheduler"] = "ok"
+		}
+
+		if err := a.checkPolling(); err != "" {
+			fail("polling", err)
+		} else {
+			report.Checks["polling"] = "ok"
+		}
+	}
+
+	writeHealthReport(w, report)
+}
+
+func (a *App) role() string {
+	if a.leaderSince.Load() == 0 {
+		return "standby"
+	}
+
+	return "leader"
+}
+
+// checkPolling returns why polling isn't getting updates through, or "" if it's fine. That includes Telegram or the
+// network failing every getUpdates, which a restart wouldn't fix. Webhook mode has no polling to check.
+func (a *App) checkPolling() string {
+	if since, stale := a.pollStale(a.Telegram.LastPollSuccess()); stale {
+		return "no successful getUpdates for " + since.Round(time.Second).String()
+	}
+
+	return ""
+}
+
+// checkPollLoop returns why the polling loop looks stuck, or "" if it's fine: no getUpdates call has returned at all,
+// successful or not, so the loop itself is wedged.
+func (a *App) checkPollLoop() string {
+	if since, stale := a.pollStale(a.Telegram.LastPollReturn()); stale {
+		return "no getUpdates call has returned for " + since.Round(time.Second).String()
+	}
+
+	return ""
+}
+
+// pollStale reports how long ago lastPoll was and whether that's past pollStaleAfter. Always fresh in webhook mode.
+// Right after taking leadership the first poll hasn't returned yet, so the clock starts at leaderSince.
+func (a *App) pollStale(lastPoll time.Time) (time.Duration, bool) {
+	if a.UpdateMode == "webhook" {
+		return 0, false
+	}
+
+	leaderSince := time.Unix(0, a.leaderSince.Load())
+
+	if lastPoll.Before(leaderSince) {
+		lastPoll = leaderSince
+	}
+
+	since := time.Since(lastPoll)
+
+	return since, since > pollStaleAfter
+}
+
+// watchPolling stops the app through stop once the leader's polling loop has been stuck for pollStaleAfter.
+// Fly health checks only report, so exiting is what gets a stuck machine restarted by its restart policy.
+// Failing getUpdates calls don't count: restarting wouldn't help during a Telegram outage, and a crash loop would
+// use up the restart policy's retries and leave the machine stopped. Those show up in /readyz and telegram_poll_errors_total.
+func (a *App) watchPolling(ctx context.Context, stop context.CancelCauseFunc) {
+	ticker := time.NewTicker(pollStaleAfter / 6)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if a.role() != "leader" {
+				continue
+			}
+
+			if reason := a.checkPollLoop(); reason != "" {
+				slog.Error("Polling is stuck, stopping so the machine gets restarted", "reason", reason)
+				stop(fmt.Errorf("%w: %s", errPollingStalled, reason))
+				return
+			}
+		}
+	}
+}
+
+func writeHealthReport(w http.ResponseWriter, report healthReport) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if report.Status != "ok" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,58 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/sriram651/go-scheduler/internal/db"
+	"github.com/sriram651/go-scheduler/internal/metrics"
+)
+
+// serveHTTP serves Prometheus metrics and the health checks on MetricsListenAddr until ctx is cancelled.
+func (a *App) serveHTTP(ctx context.Context) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", metrics.Handler())
+	mux.HandleFunc("GET /healthz", a.handleHealthz)
+	mux.HandleFunc("GET /readyz", a.handleReadyz)
+
+	server := &http.Server{
+		Addr:              a.MetricsListenAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	serverErr := make(chan error, 1)
+
+	go func() {
+		slog.Info("Metrics and health server listening", "addr", a.MetricsListenAddr)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case <-ctx.Done():
+	case err := <-serverErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("Metrics and health server stopped", "error", err)
+		}
+
+		return
+	}
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer shutdownCancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Error("Error shutting down the metrics and health server", "error", err)
+	}
+}
+
+// countSubscribedUsers backs the subscribed_users gauge, read on every scrape.
+func (a *App) countSubscribedUsers() (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	return db.CountSubscribedUsers(ctx, a.Database)
+}
@@ -0,0 +1,62 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/sriram651/go-scheduler/internal/db"
+	"github.com/sriram651/go-scheduler/internal/scheduler"
+)
+
+// Name of the job registered from --schedule when the jobs table is empty
+const defaultJobName = "broadcast"
+
+// jobHandlers maps the `handler` column of the jobs table to the code it runs.
+func (a *App) jobHandlers() map[string]scheduler.Handler {
+	return map[string]scheduler.Handler{
+		"broadcast": a.Broadcast.Run,
+	}
+}
+
+// registerJobs loads the jobs table into the scheduler. With no rows it falls back to a single
+// broadcast job on defaultSchedule, so a fresh install behaves exactly like the single-cron setup.
+// That job delays overlapping ticks rather than skipping them: a skipped tick would never send to that hour's users.
+func (a *App) registerJobs(ctx context.Context, defaultSchedule string) {
+	jobs, err := db.GetJobs(ctx, a.Database)
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Could not load jobs table, falling back to --schedule", "error", err)
+	}
+
+	if len(jobs) == 0 {
+		jobs = []db.Job{{Name: defaultJobName, Schedule: defaultSchedule, Handler: "broadcast", Enabled: true, Overlap: string(scheduler.OverlapDelay)}}
+	}
+
+	handlers := a.jobHandlers()
+
+	for _, job := range jobs {
+		handler, ok := handlers[job.Handler]
+
+		if !ok {
+			slog.ErrorContext(ctx, "Unknown job handler, skipping", "job", job.Name, "handler", job.Handler)
+			continue
+		}
+
+		registerErr := a.Scheduler.Register(scheduler.Job{
+			Name:     job.Name,
+			Schedule: job.Schedule,
+			Enabled:  job.Enabled,
+			Overlap:  scheduler.OverlapPolicy(job.Overlap),
+			Handler:  handler,
+		})
+
+		if registerErr != nil {
+			slog.ErrorContext(ctx, "Could not register job", "job", job.Name, "error", registerErr)
+			continue
+		}
+
+		if job.Handler == "broadcast" {
+			a.catchUpJobs = append(a.catchUpJobs, job.Name)
+		}
+	}
+}
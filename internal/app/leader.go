@@ -0,0 +1,113 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/sriram651/go-scheduler/internal/db"
+)
+
+// Advisory lock key shared by every instance of this service ("gosched" in ASCII)
+const leaderLockKey int64 = 0x676f7363686564
+
+// waitForLeadership retries the leader lock every LeaderRetryInterval until it is acquired. Returns nil once ctx is cancelled.
+func (a *App) waitForLeadership(ctx context.Context) *db.AdvisoryLock {
+	standbyLogged := false
+
+	for {
+		lock, err := db.TryAdvisoryLock(ctx, a.Database, leaderLockKey)
+
+		if err != nil {
+			slog.Warn("Error trying the leader lock", "error", err)
+		} else if lock != nil {
+			slog.Info("Leader lock acquired, starting polling and scheduler")
+			return lock
+		} else if !standbyLogged {
+			slog.Info("Another instance is leader, waiting on standby")
+			standbyLogged = true
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(a.LeaderRetryInterval):
+		}
+	}
+}
+
+// removeWebhook deletes the Telegram webhook on shutdown, provided this instance still holds lock,
+// so a leader that has quietly lost it can't wipe its successor's registration.
+func (a *App) removeWebhook(lock *db.AdvisoryLock) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := lock.Ping(ctx); err != nil {
+		slog.Warn("Leader lock is gone, leaving the webhook to the new leader", "error", err)
+		return
+	}
+
+	if err := a.Telegram.DeleteWebhook(ctx); err != nil {
+		slog.Error("Error removing the webhook", "error", err)
+		return
+	}
+
+	slog.Info("Webhook removed")
+}
+
+// leadWebhook has this instance handle webhook updates for the term, and records its machine so standby instances
+// can send the requests they get on to it.
+func (a *App) leadWebhook(ctx context.Context) {
+	a.Telegram.Lead(ctx)
+
+	if a.machineID == "" {
+		return
+	}
+
+	if err := db.SetLeaderMachine(ctx, a.Database, a.machineID); err != nil {
+		slog.ErrorContext(ctx, "Error recording the leader machine, standby instances can't forward webhook updates", "error", err)
+	}
+}
+
+// forwardToLeader answers a webhook request that reached a standby instance. On Fly the fly-replay header has the proxy
+// replay it on the leader's machine. Otherwise, or if the leader isn't known, a 503 has Telegram deliver the update again later.
+func (a *App) forwardToLeader(w http.ResponseWriter, r *http.Request) {
+	if a.machineID != "" {
+		leaderMachine, err := db.GetLeaderMachine(r.Context(), a.Database)
+
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error looking up the leader machine", "error", err)
+		} else if leaderMachine != "" && leaderMachine != a.machineID {
+			w.Header().Set("fly-replay", "instance="+leaderMachine)
+		}
+	}
+
+	http.Error(w, "not the leader", http.StatusServiceUnavailable)
+}
+
+// watchLeadership pings the lock's session every LeaderRetryInterval and calls lost if it goes away,
+// since Postgres drops session-level advisory locks along with the session.
+func (a *App) watchLeadership(ctx context.Context, lock *db.AdvisoryLock, lost context.CancelFunc) {
+	ticker := time.NewTicker(a.LeaderRetryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			pingCtx, pingCancel := context.WithTimeout(ctx, 5*time.Second)
+
+			pingErr := lock.Ping(pingCtx)
+
+			pingCancel()
+
+			if pingErr != nil && ctx.Err() == nil {
+				slog.Error("Leader lock session is gone", "error", pingErr)
+				lost()
+				return
+			}
+		}
+	}
+}
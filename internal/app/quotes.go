@@ -0,0 +1,73 @@
+package app
+
+import (
+	"database/sql"
+	"log/slog"
+
+	"github.com/sriram651/go-scheduler/internal/config"
+	"github.com/sriram651/go-scheduler/internal/logging"
+	"github.com/sriram651/go-scheduler/internal/metrics"
+	"github.com/sriram651/go-scheduler/internal/quote"
+)
+
+// newQuoteProvider builds the provider named by --quote-provider. More than one name makes a fallback chain
+// tried in the given order. A misconfigured provider stops startup.
+func newQuoteProvider(cfg config.Config, database *sql.DB) quote.QuoteProvider {
+	names := cfg.QuoteProviders
+
+	if len(names) == 0 {
+		names = []string{"api"}
+	}
+
+	var providers []quote.QuoteProvider
+
+	for _, name := range names {
+		switch name {
+		case "api":
+			var apiProvider quote.QuoteProvider = quote.NewClient(cfg.QuotesBaseURL, cfg.QuoteTimeout)
+
+			// Fail fast while the API is down instead of waiting out its timeout on every fetch
+			if cfg.QuoteBreakerThreshold > 0 {
+				breaker := quote.NewBreaker(apiProvider, cfg.QuoteBreakerThreshold, cfg.QuoteBreakerCooldown)
+
+				breakerState := metrics.QuoteBreakerState.WithLabelValues(breaker.Name())
+				breakerState.Set(float64(quote.BreakerClosed))
+
+				breaker.OnStateChange = func(from quote.BreakerState, to quote.BreakerState) {
+					breakerState.Set(float64(to))
+				}
+
+				apiProvider = breaker
+			}
+
+			providers = append(providers, apiProvider)
+		case "file":
+			if cfg.QuoteFile == "" {
+				logging.Fatal("The file quote provider needs --quote-file")
+			}
+
+			fileProvider, err := quote.NewFileProvider(cfg.QuoteFile)
+
+			if err != nil {
+				logging.Fatal("Could not load quote file", "error", err)
+			}
+
+			providers = append(providers, fileProvider)
+		case "postgres":
+			providers = append(providers, quote.NewPostgresProvider(database))
+		default:
+			logging.Fatal("Unknown quote provider (expected api, file or postgres)", "provider", name)
+		}
+	}
+
+	if len(providers) == 1 {
+		slog.Info("Quote provider configured", "provider", providers[0].Name())
+		return providers[0]
+	}
+
+	chain := quote.NewChain(providers...)
+
+	slog.Info("Quote providers configured", "providers", chain.Name())
+
+	return chain
+}
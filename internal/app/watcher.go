@@ -0,0 +1,47 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/sriram651/go-scheduler/internal/db"
+)
+
+// watchBotConfig re-reads bot_config every ConfigRefreshInterval and pushes changes to the components holding a copy,
+// so edits made straight in the DB (or by /sethour on another instance) apply without a restart.
+func (a *App) watchBotConfig(ctx context.Context) {
+	if a.ConfigRefreshInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(a.ConfigRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sendHour, err := db.GetSendHour(ctx, a.Database)
+
+			if err != nil {
+				if ctx.Err() == nil {
+					slog.WarnContext(ctx, "Error refreshing send_hour from bot_config", "error", err)
+				}
+				continue
+			}
+
+			// Changes made locally by /sethour are already applied and land here as no-ops
+			previousSendHour := a.Telegram.SendHour()
+
+			if int(sendHour) == previousSendHour {
+				continue
+			}
+
+			slog.InfoContext(ctx, "send_hour changed in bot_config", "from", previousSendHour, "to", sendHour)
+
+			a.updateSendHour(int(sendHour))
+		}
+	}
+}
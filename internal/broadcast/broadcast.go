@@ -3,85 +3,330 @@ package broadcast
 import (
 	"context"
 	"database/sql"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/sriram651/go-scheduler/internal/db"
+	"github.com/sriram651/go-scheduler/internal/logging"
+	"github.com/sriram651/go-scheduler/internal/metrics"
 	"github.com/sriram651/go-scheduler/internal/quote"
 	"github.com/sriram651/go-scheduler/internal/telegram"
+	"github.com/sriram651/go-scheduler/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = tracing.Tracer("broadcast")
+
 type Broadcast struct {
-	Quote    *quote.Client
+	Quote    quote.QuoteProvider
 	Telegram *telegram.Client
-	sendHour int
+	sendHour atomic.Int64
 	Database *sql.DB
+	Workers  int
+
+	// Sent when the quote provider has nothing to offer
+	DefaultQuote string
+
+	// How many times a transient send failure is retried before giving up on that user
+	MaxRetries int
+}
+
+// Per-run delivery counts. Permanent is the subset of Failed that retrying could never fix,
+// and Deactivated the subset of Permanent whose users were unsubscribed because of it.
+// Skipped users already had their quote for the day.
+type runStats struct {
+	Success     int
+	Failed      int
+	Permanent   int
+	Deactivated int
+	Skipped     int
 }
 
-func NewClient(qc *quote.Client, tc *telegram.Client, database *sql.DB) *Broadcast {
+func NewClient(qp quote.QuoteProvider, tc *telegram.Client, database *sql.DB, defaultQuote string, workers int, maxRetries int) *Broadcast {
+	// Always keep at least one worker so a bad config can't stall every run
+	if workers < 1 {
+		workers = 1
+	}
+
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
 
 	return &Broadcast{
-		Quote:    qc,
-		Telegram: tc,
-		Database: database,
+		Quote:        qp,
+		Telegram:     tc,
+		Database:     database,
+		DefaultQuote: defaultQuote,
+		Workers:      workers,
+		MaxRetries:   maxRetries,
 	}
 }
 
 func (b *Broadcast) UpdateSendHour(newSendHour int) {
-	b.sendHour = newSendHour
+	b.sendHour.Store(int64(newSendHour))
 }
 
-func (b *Broadcast) Run(ctx context.Context, nowUTC time.Time) {
-	log.Println("🚀 Cron run started")
+// Run sends one quote to every subscriber whose local send hour matches nowUTC.
+// It returns an error only if no one could be reached: the user query failed or every send failed.
+func (b *Broadcast) Run(ctx context.Context, nowUTC time.Time) error {
+	ctx, span := tracer.Start(ctx, "broadcast.Run", trace.WithAttributes(
+		attribute.String("job", metrics.Job(ctx)),
+		attribute.String("fired_at", nowUTC.Format(time.RFC3339)),
+	))
+
+	err := b.run(ctx, nowUTC)
+
+	tracing.End(span, err)
 
-	var success, failure int
+	return err
+}
 
-	var broadcastMessage string
+func (b *Broadcast) run(ctx context.Context, nowUTC time.Time) error {
+	slog.InfoContext(ctx, "Broadcast run started")
 
-	quoteCtx, quoteCancel := context.WithTimeout(ctx, 5*time.Second)
+	startedAt := time.Now()
 
-	broadcastMessage, quoteFetchErr := b.Quote.GetQuote(quoteCtx)
+	recipients, getSubscribedUsersErr := db.GetSubscribedUsersForHour(ctx, b.Database, nowUTC, int(b.sendHour.Load()))
 
-	if quoteFetchErr != nil {
-		log.Println(quoteFetchErr)
+	if getSubscribedUsersErr != nil {
+		slog.ErrorContext(ctx, "Broadcast run failed, could not fetch subscribed users", "error", getSubscribedUsersErr)
+		metrics.BroadcastRuns.WithLabelValues(metrics.Job(ctx), "failed").Inc()
+		return fmt.Errorf("could not fetch subscribed users: %w", getSubscribedUsersErr)
 	}
 
-	quoteCancel()
+	freshByCategory, categoryByUser := b.fetchQuotesByCategory(ctx, recipients)
+
+	subscribedUsers := make([]int64, 0, len(recipients))
 
-	if broadcastMessage == "" || quoteFetchErr != nil {
-		broadcastMessage = b.Quote.DefaultQuote
+	for _, recipient := range recipients {
+		subscribedUsers = append(subscribedUsers, recipient.ChatID)
 	}
 
-	subscribedUsers, getSubscribedUsersErr := db.GetSubscribedUsersForHour(ctx, b.Database, nowUTC, b.sendHour)
+	// The run's headline quote is the uncategorized one; per-user quotes are linked from broadcast_deliveries
+	runQuote, ok := freshByCategory[""]
 
-	if getSubscribedUsersErr != nil {
-		log.Println("❌ Cron failed — could not fetch subscribed users:", getSubscribedUsersErr)
-		return
+	if !ok && len(recipients) > 0 {
+		runQuote = freshByCategory[recipients[0].Category]
 	}
 
-	for _, user := range subscribedUsers {
-		sendCtx, sendCancel := context.WithTimeout(ctx, 5*time.Second)
+	// Run history is best-effort: a failed insert leaves runID at 0 and the broadcast still goes out
+	runID, _ := db.StartBroadcastRun(ctx, b.Database, db.RunScheduled, nowUTC, runQuote.Text, len(subscribedUsers))
 
-		sendMessageError := b.Telegram.HandleSend(sendCtx, user, broadcastMessage, nil)
+	ctx = logging.With(ctx, "run_id", runID)
 
-		sendCancel()
+	stats := b.fanOut(ctx, runID, nowUTC, subscribedUsers, b.unseenQuotes(freshByCategory, categoryByUser), true)
 
-		if sendMessageError != nil {
-			log.Printf("⚠️ Send to user %d failed: %s", user, sendMessageError)
-			failure++
-			continue
-		}
+	trace.SpanFromContext(ctx).SetAttributes(
+		attribute.Int64("run_id", runID),
+		attribute.Int("recipients", len(subscribedUsers)),
+		attribute.Int("sent", stats.Success),
+		attribute.Int("failed", stats.Failed),
+		attribute.Int("skipped", stats.Skipped),
+	)
 
-		success++
+	if runID != 0 {
+		db.FinishBroadcastRun(context.WithoutCancel(ctx), b.Database, runID, stats.Success, stats.Failed, time.Since(startedAt))
 	}
 
-	if failure > 0 {
-		if success == 0 {
-			log.Println("❌ Cron run failed for all users - Failed:", failure)
+	statsAttrs := []any{"sent", stats.Success, "failed", stats.Failed, "permanent", stats.Permanent, "unsubscribed", stats.Deactivated, "skipped", stats.Skipped}
+
+	if stats.Failed > 0 {
+		if stats.Success == 0 {
+			slog.ErrorContext(ctx, "Broadcast run failed for all users", statsAttrs...)
 		} else {
-			log.Printf("⚠️ Partial success — Successful: %d, Failed: %d", success, failure)
+			slog.WarnContext(ctx, "Broadcast run partially succeeded", statsAttrs...)
 		}
 	} else {
-		log.Printf("✅ Cron successful — %d messages sent", success)
+		slog.InfoContext(ctx, "Broadcast run succeeded", statsAttrs...)
+	}
+
+	if stats.Failed > 0 && stats.Success == 0 {
+		metrics.BroadcastRuns.WithLabelValues(metrics.Job(ctx), "failed").Inc()
+		return fmt.Errorf("all %d sends failed", stats.Failed)
+	}
+
+	metrics.BroadcastRuns.WithLabelValues(metrics.Job(ctx), "ok").Inc()
+
+	return nil
+}
+
+// PreviewQuote fetches the quote a broadcast would send right now, falling back to DefaultQuote on any error.
+func (b *Broadcast) PreviewQuote(ctx context.Context) string {
+	return b.fetchQuote(ctx, "").Text
+}
+
+// SendNow sends an ad-hoc message to every subscribed user, regardless of send hour or the daily delivery ledger.
+// The run is recorded in broadcast history like a scheduled one.
+func (b *Broadcast) SendNow(ctx context.Context, text string) (int, int, error) {
+	slog.InfoContext(ctx, "Ad-hoc broadcast started")
+
+	startedAt := time.Now()
+
+	subscribedUsers, err := db.GetSubscribedUsers(ctx, b.Database)
+
+	if err != nil {
+		return 0, 0, fmt.Errorf("could not fetch subscribed users: %w", err)
+	}
+
+	runID, _ := db.StartBroadcastRun(ctx, b.Database, db.RunAdhoc, startedAt.UTC(), text, len(subscribedUsers))
+
+	ctx = logging.With(ctx, "run_id", runID)
+
+	stats := b.fanOut(ctx, runID, startedAt.UTC(), subscribedUsers, sameMessage(outgoing{Text: text}), false)
+
+	if runID != 0 {
+		db.FinishBroadcastRun(context.WithoutCancel(ctx), b.Database, runID, stats.Success, stats.Failed, time.Since(startedAt))
 	}
+
+	slog.InfoContext(ctx, "Ad-hoc broadcast done", "sent", stats.Success, "failed", stats.Failed)
+
+	return stats.Success, stats.Failed, nil
+}
+
+// fanOut sends each user the message picked by messageFor through a pool of at most b.Workers goroutines,
+// recording each delivery against runID.
+// With oncePerDay set, users who already got a quote for their local date at nowUTC are skipped.
+// Once ctx is cancelled no new sends are started, but in-flight ones finish (each bounded by the send timeout) and are
+// recorded, so counts stay accurate.
+func (b *Broadcast) fanOut(ctx context.Context, runID int64, nowUTC time.Time, users []int64, messageFor messageFunc, oncePerDay bool) runStats {
+	var stats runStats
+
+	var countMutex sync.Mutex
+	var workerWaitGroup sync.WaitGroup
+
+	jobs := make(chan int64)
+
+	jobName := metrics.Job(ctx)
+
+	for range min(b.Workers, len(users)) {
+		workerWaitGroup.Add(1)
+
+		go func() {
+			defer workerWaitGroup.Done()
+
+			for user := range jobs {
+				ctx := logging.With(ctx, "chat_id", user)
+
+				if oncePerDay {
+					claimed, claimErr := db.ClaimDailyDelivery(ctx, b.Database, user, nowUTC)
+
+					// Without a claim we can't rule out a duplicate, so the user is left for the next run
+					if claimErr != nil || !claimed {
+						countMutex.Lock()
+
+						if claimErr != nil {
+							stats.Failed++
+							metrics.BroadcastSends.WithLabelValues(jobName, db.DeliveryFailed).Inc()
+						} else {
+							stats.Skipped++
+							metrics.BroadcastSends.WithLabelValues(jobName, "skipped").Inc()
+						}
+
+						countMutex.Unlock()
+						continue
+					}
+				}
+
+				message := messageFor(ctx, user)
+
+				sendStartedAt := time.Now()
+
+				sendMessageError := b.sendWithRetry(ctx, user, message.Text)
+
+				metrics.SendDuration.WithLabelValues(jobName).Observe(time.Since(sendStartedAt).Seconds())
+
+				if sendMessageError != nil && oncePerDay {
+					db.ReleaseDailyDelivery(context.WithoutCancel(ctx), b.Database, user, nowUTC)
+				}
+
+				if sendMessageError == nil && message.QuoteID != 0 {
+					db.RecordUserQuote(context.WithoutCancel(ctx), b.Database, user, message.QuoteID)
+				}
+
+				var deactivated bool
+
+				deliveryStatus := db.DeliverySent
+
+				if sendMessageError != nil {
+					slog.WarnContext(ctx, "Send failed", "error", sendMessageError)
+					deactivated = b.deactivateUnreachable(context.WithoutCancel(ctx), user, sendMessageError)
+
+					deliveryStatus = db.DeliveryFailed
+
+					if isPermanent(sendMessageError) {
+						deliveryStatus = db.DeliveryPermanent
+					}
+				}
+
+				metrics.BroadcastSends.WithLabelValues(jobName, deliveryStatus).Inc()
+
+				if runID != 0 {
+					db.AddBroadcastDelivery(context.WithoutCancel(ctx), b.Database, runID, user, message.QuoteID, deliveryStatus, sendMessageError)
+				}
+
+				countMutex.Lock()
+
+				if sendMessageError != nil {
+					stats.Failed++
+
+					if isPermanent(sendMessageError) {
+						stats.Permanent++
+					}
+
+					if deactivated {
+						stats.Deactivated++
+					}
+				} else {
+					stats.Success++
+				}
+
+				countMutex.Unlock()
+			}
+		}()
+	}
+
+Outer:
+	for i, user := range users {
+		select {
+		case jobs <- user:
+		case <-ctx.Done():
+			slog.WarnContext(ctx, "Broadcast cancelled", "not_attempted", len(users)-i)
+			break Outer
+		}
+	}
+
+	close(jobs)
+
+	// Wait for the in-flight sends to finish before reporting
+	workerWaitGroup.Wait()
+
+	return stats
+}
+
+// deactivateUnreachable unsubscribes users who blocked the bot, deleted their account or whose chat is gone,
+// so they aren't retried on every future run. Reports whether the user was unsubscribed.
+func (b *Broadcast) deactivateUnreachable(ctx context.Context, user int64, sendErr error) bool {
+	var reason string
+
+	switch {
+	case errors.Is(sendErr, telegram.ErrBotBlocked):
+		reason = "blocked"
+	case errors.Is(sendErr, telegram.ErrUserDeactivated):
+		reason = "deactivated"
+	case errors.Is(sendErr, telegram.ErrChatNotFound):
+		reason = "chat_not_found"
+	default:
+		return false
+	}
+
+	if err := db.MarkUserInactive(context.WithoutCancel(ctx), b.Database, user, reason); err != nil {
+		return false
+	}
+
+	return true
 }
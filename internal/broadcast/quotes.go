@@ -0,0 +1,149 @@
+package broadcast
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/sriram651/go-scheduler/internal/db"
+	"github.com/sriram651/go-scheduler/internal/metrics"
+	"github.com/sriram651/go-scheduler/internal/quote"
+	"github.com/sriram651/go-scheduler/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// outgoing is what a single user is sent. QuoteID is the quotes-table row, 0 for messages that aren't cached quotes.
+// Fallback marks the DEFAULT_QUOTE stand-in used when the provider had nothing.
+type outgoing struct {
+	Text     string
+	QuoteID  int64
+	Fallback bool
+}
+
+// messageFunc picks the message for one user of a fan-out.
+type messageFunc func(ctx context.Context, user int64) outgoing
+
+// fetchQuote gets a fresh quote in category ("" for any) from the quote provider and caches it.
+// Falls back to DefaultQuote (uncached, marked Fallback) on any error.
+func (b *Broadcast) fetchQuote(ctx context.Context, category string) outgoing {
+	// No timeout here: the API provider bounds its own requests, and a fallback chain needs time left
+	// for the providers after a slow one
+	fetchStartedAt := time.Now()
+
+	fetchCtx, span := tracer.Start(ctx, "quote.FetchQuote", trace.WithAttributes(
+		attribute.String("quote.provider", b.Quote.Name()),
+		attribute.String("quote.category", category),
+	))
+
+	fetchedQuote, quoteFetchErr := b.Quote.FetchQuote(fetchCtx, category)
+
+	tracing.End(span, quoteFetchErr)
+
+	b.recordQuoteFetch(ctx, time.Since(fetchStartedAt), quoteFetchErr)
+
+	if quoteFetchErr != nil || fetchedQuote.Text == "" {
+		if quoteFetchErr != nil {
+			slog.WarnContext(ctx, "Quote fetch failed, using the fallback", "category", category, "error", quoteFetchErr)
+		}
+
+		return outgoing{Text: b.DefaultQuote, Fallback: true}
+	}
+
+	fresh := outgoing{Text: fetchedQuote.Format()}
+
+	// Quotes without an id can't be deduplicated, so they're sent without being cached
+	if fetchedQuote.ID == "" {
+		return fresh
+	}
+
+	quoteID, saveErr := db.SaveQuote(ctx, b.Database, fetchedQuote.ID, fetchedQuote.Text, fetchedQuote.Author, fetchedQuote.Category)
+
+	if saveErr == nil {
+		fresh.QuoteID = quoteID
+	}
+
+	return fresh
+}
+
+func (b *Broadcast) recordQuoteFetch(ctx context.Context, took time.Duration, err error) {
+	outcome := "ok"
+
+	if err != nil {
+		outcome = "error"
+	}
+
+	metrics.QuoteFetches.WithLabelValues(metrics.Job(ctx), b.Quote.Name(), outcome).Inc()
+	metrics.QuoteFetchDuration.WithLabelValues(metrics.Job(ctx), b.Quote.Name()).Observe(took.Seconds())
+}
+
+// fetchQuotesByCategory fetches one fresh quote per distinct category among recipients, so each category group
+// gets a matching quote. It also returns each recipient's category keyed by chat ID.
+func (b *Broadcast) fetchQuotesByCategory(ctx context.Context, recipients []db.Recipient) (map[string]outgoing, map[int64]string) {
+	freshByCategory := make(map[string]outgoing)
+	categoryByUser := make(map[int64]string, len(recipients))
+
+	for _, recipient := range recipients {
+		categoryByUser[recipient.ChatID] = recipient.Category
+
+		if _, fetched := freshByCategory[recipient.Category]; !fetched {
+			freshByCategory[recipient.Category] = b.fetchQuote(ctx, recipient.Category)
+		}
+	}
+
+	return freshByCategory, categoryByUser
+}
+
+// unseenQuotes returns a messageFunc that sends each user their category's fresh quote if they haven't had it yet,
+// otherwise a random cached quote from that category they haven't seen, and the fresh quote again only once they've seen them all.
+// When the fetch failed and there's nothing cached left, each user gets their own unused quote from the curated library
+// before anyone is sent DEFAULT_QUOTE.
+func (b *Broadcast) unseenQuotes(freshByCategory map[string]outgoing, categoryByUser map[int64]string) messageFunc {
+	return func(ctx context.Context, user int64) outgoing {
+		category := categoryByUser[user]
+		fresh := freshByCategory[category]
+
+		cached, ok, err := db.PickUnseenQuote(ctx, b.Database, user, fresh.QuoteID, category)
+
+		if err != nil || !ok {
+			if fresh.Fallback {
+				return b.libraryQuote(ctx, user, category, fresh)
+			}
+
+			return fresh
+		}
+
+		return outgoing{
+			Text:    quote.Quote{Text: cached.Text, Author: cached.Author}.Format(),
+			QuoteID: cached.ID,
+		}
+	}
+}
+
+// libraryQuote picks a curated quote user hasn't been sent yet, preferring category, and caches it under its
+// "curated:<id>" key so it's recorded like any other quote. Returns otherwise if the library has nothing left for them.
+func (b *Broadcast) libraryQuote(ctx context.Context, user int64, category string, otherwise outgoing) outgoing {
+	curated, ok, err := db.PickUnusedCuratedQuote(ctx, b.Database, user, category)
+
+	if err != nil || !ok {
+		return otherwise
+	}
+
+	library := outgoing{Text: quote.Quote{Text: curated.Text, Author: curated.Author}.Format()}
+
+	quoteID, saveErr := db.SaveQuote(ctx, b.Database, "curated:"+strconv.FormatInt(curated.ID, 10), curated.Text, curated.Author, curated.Category)
+
+	if saveErr == nil {
+		library.QuoteID = quoteID
+	}
+
+	return library
+}
+
+// sameMessage returns a messageFunc that sends everyone msg.
+func sameMessage(msg outgoing) messageFunc {
+	return func(ctx context.Context, user int64) outgoing {
+		return msg
+	}
+}
@@ -0,0 +1,73 @@
+package broadcast
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"github.com/sriram651/go-scheduler/internal/telegram"
+)
+
+const (
+	retryBaseDelay = 1 * time.Second
+	retryMaxDelay  = 30 * time.Second
+)
+
+// isPermanent reports whether retrying err can never succeed, e.g. 400 bad request or 403 blocked by the user.
+// Network errors, timeouts, 5xx and 429 are all treated as transient.
+func isPermanent(err error) bool {
+	var apiErr *telegram.APIError
+
+	if errors.As(err, &apiErr) {
+		return !apiErr.Temporary()
+	}
+
+	return false
+}
+
+// backoff returns the wait before retry number `attempt` (1-based): exponential growth capped at retryMaxDelay,
+// with half of it jittered so workers that failed together don't retry together.
+func backoff(attempt int) time.Duration {
+	delay := min(retryBaseDelay<<(attempt-1), retryMaxDelay)
+
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// sendWithRetry sends to a single user, retrying transient failures up to b.MaxRetries times.
+// Cancelling ctx stops further retries, but an attempt already under way runs to completion (bounded by the send
+// timeout), so a message isn't cut off after Telegram may already have delivered it.
+func (b *Broadcast) sendWithRetry(ctx context.Context, user int64, message string) error {
+	for attempt := 0; ; attempt++ {
+		sendCtx, sendCancel := context.WithTimeout(context.WithoutCancel(ctx), b.Telegram.SendTimeout())
+
+		sendErr := b.Telegram.HandleSend(sendCtx, user, message, nil)
+
+		sendCancel()
+
+		if sendErr == nil || isPermanent(sendErr) || attempt == b.MaxRetries || ctx.Err() != nil {
+			return sendErr
+		}
+
+		delay := backoff(attempt + 1)
+
+		// A flood wait is longer than the send timeout can cover, so it is waited out here
+		var apiErr *telegram.APIError
+
+		if errors.As(sendErr, &apiErr) && apiErr.RetryAfter > delay {
+			delay = apiErr.RetryAfter
+		}
+
+		slog.WarnContext(ctx, "Send failed, retrying", "error", sendErr, "delay", delay.String(), "attempt", attempt+1, "max_retries", b.MaxRetries)
+
+		timer := time.NewTimer(delay)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return sendErr
+		case <-timer.C:
+		}
+	}
+}
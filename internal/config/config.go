@@ -1,9 +1,15 @@
 package config
 
 import (
+	"errors"
 	"flag"
-	"log"
+	"fmt"
+	"io"
+	"maps"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -13,33 +19,316 @@ type Config struct {
 	TelegramBaseURL     string
 	TelegramToken       string
 	TelegramPollTimeout time.Duration
+	SendTimeout         time.Duration
+	UpdateMode          string
+	WebhookURL          string
+	WebhookSecret       string
+	WebhookListenAddr   string
+	MetricsListenAddr   string
+	LogFormat           string
+	LogLevel            string
+	Tracing             bool
 	QuotesBaseURL       string
+	QuoteTimeout        time.Duration
 	DefaultQuote        string
+	QuoteProviders      []string
+	QuoteFile           string
 	Schedule            string
+	BroadcastWorkers    int
+	SendRetries         int
+	CatchUpWindow       time.Duration
+	LeaderRetryInterval time.Duration
+	ConfigRefresh       time.Duration
+
+	// Circuit breaker around the quote API: consecutive failures before it opens (0 disables), and how long it stays open
+	QuoteBreakerThreshold int
+	QuoteBreakerCooldown  time.Duration
 
 	DatabaseURL string
+
+	// Chats allowed to run admin commands, from the comma-separated ADMIN_CHAT_IDS
+	AdminChatIDs []int64
+
+	// The YAML or TOML file the config was layered on, empty if none
+	ConfigFile string
+
+	// Positional arguments left after the flags, e.g. ["migrate"]
+	Args []string
+}
+
+// Short flags and the setting they are an alias of
+var flagAliases = map[string]string{
+	"s": "schedule",
+	"w": "workers",
+	"r": "retries",
 }
 
-// TODO: ENV Vars validation
-func LoadConfig() Config {
+// LoadConfig builds the config from, lowest precedence first: the defaults, the config file (--config or CONFIG_FILE),
+// env vars (including .env) and the command line flags in args. Every setting has a config file key, e.g. catchup_window,
+// and the env var of the same name in upper case, e.g. CATCHUP_WINDOW.
+// The returned error is a *ValidationError listing every problem found, not just the first.
+func LoadConfig(args []string) (Config, error) {
 	godotenv.Load()
 
-	log.Println("✅ Env loaded")
+	var cfg Config
+	var quoteProviders string
+	var adminChatIDs string
+
+	flags := flag.NewFlagSet("scheduler", flag.ContinueOnError)
+
+	flags.StringVar(&cfg.ConfigFile, "config", os.Getenv("CONFIG_FILE"), "YAML or TOML config file, overridden by env vars and flags")
+
+	flags.StringVar(&cfg.Schedule, "schedule", "0 * * * *", "Cron schedule that controls when the reminder is sent (supports standard cron syntax and @every intervals)")
+	flags.StringVar(&cfg.Schedule, "s", "0 * * * *", "Cron schedule that controls when the reminder is sent (supports standard cron syntax and @every intervals)")
+
+	flags.IntVar(&cfg.BroadcastWorkers, "workers", 5, "The maximum number of concurrent Telegram sends during a broadcast")
+	flags.IntVar(&cfg.BroadcastWorkers, "w", 5, "The maximum number of concurrent Telegram sends during a broadcast")
+
+	flags.IntVar(&cfg.SendRetries, "retries", 3, "How many times a transient send failure (network error, 5xx, 429) is retried with backoff")
+	flags.IntVar(&cfg.SendRetries, "r", 3, "How many times a transient send failure (network error, 5xx, 429) is retried with backoff")
+
+	flags.DurationVar(&cfg.CatchUpWindow, "catchup-window", 6*time.Hour, "How far back missed broadcast ticks are replayed on startup (0 disables catch-up)")
+
+	flags.DurationVar(&cfg.LeaderRetryInterval, "leader-retry", 15*time.Second, "How often a standby instance retries the leader lock")
+
+	flags.DurationVar(&cfg.ConfigRefresh, "config-refresh", time.Minute, "How often bot_config is re-read so changes apply without a restart (0 disables)")
+
+	flags.StringVar(&cfg.UpdateMode, "update-mode", "polling", "How Telegram updates are received: \"polling\" (getUpdates) or \"webhook\"")
+	flags.StringVar(&cfg.WebhookListenAddr, "webhook-addr", ":8080", "Address the webhook server listens on in webhook mode")
+	flags.StringVar(&cfg.MetricsListenAddr, "metrics-addr", ":9090", "Address the server for /metrics, /healthz and /readyz listens on (empty disables it)")
+
+	flags.DurationVar(&cfg.TelegramPollTimeout, "poll-timeout", 60*time.Second, "How long Telegram holds a getUpdates long poll open when there are no updates")
+	flags.DurationVar(&cfg.SendTimeout, "send-timeout", 5*time.Second, "Timeout for a single Telegram API call such as sendMessage, including rate limiter waits")
+
+	flags.StringVar(&quoteProviders, "quote-provider", "api", "Comma-separated quote providers tried in order: \"api\", \"file\", \"postgres\"")
+	flags.StringVar(&cfg.QuoteFile, "quote-file", "", "JSON or CSV file served by the \"file\" quote provider")
+	flags.DurationVar(&cfg.QuoteTimeout, "quote-timeout", 5*time.Second, "Timeout for a single request to the quote API")
+
+	flags.StringVar(&cfg.LogFormat, "log-format", "text", "Log output format: \"text\" or \"json\"")
+	flags.StringVar(&cfg.LogLevel, "log-level", "info", "Minimum log level: \"debug\", \"info\", \"warn\" or \"error\"")
+
+	flags.BoolVar(&cfg.Tracing, "tracing", false, "Export OpenTelemetry traces over OTLP/HTTP, configured by the OTEL_EXPORTER_OTLP_* env vars")
+
+	flags.IntVar(&cfg.QuoteBreakerThreshold, "quote-breaker-threshold", 3, "Consecutive quote API failures before the circuit breaker opens (0 disables the breaker)")
+	flags.DurationVar(&cfg.QuoteBreakerCooldown, "quote-breaker-cooldown", time.Minute, "How long the quote API circuit stays open before a probe request is let through")
+
+	flagProblems := parseFlags(flags, args)
+
+	cfg.Args = flags.Args()
+
+	// Secrets and deployment URLs are never flags, so they don't show up in the process list
+	cfg.TelegramBaseURL = "https://api.telegram.org/bot"
+
+	envOnly := map[string]*string{
+		"tg_bot_token":      &cfg.TelegramToken,
+		"tg_api_base_url":   &cfg.TelegramBaseURL,
+		"tg_webhook_url":    &cfg.WebhookURL,
+		"tg_webhook_secret": &cfg.WebhookSecret,
+		"quote_api_url":     &cfg.QuotesBaseURL,
+		"default_quote":     &cfg.DefaultQuote,
+		"database_url":      &cfg.DatabaseURL,
+		"admin_chat_ids":    &adminChatIDs,
+	}
+
+	setOnCommandLine := make(map[string]bool)
+
+	flags.Visit(func(f *flag.Flag) {
+		if name, ok := flagAliases[f.Name]; ok {
+			setOnCommandLine[name] = true
+		} else {
+			setOnCommandLine[f.Name] = true
+		}
+	})
+
+	// Every setting by its config file key, with a setter the file and env layers go through
+	settings := make(map[string]func(value string) error)
+
+	flags.VisitAll(func(f *flag.Flag) {
+		if _, ok := flagAliases[f.Name]; ok || f.Name == "config" {
+			return
+		}
+
+		settings[strings.ReplaceAll(f.Name, "-", "_")] = func(value string) error {
+			// The command line wins over both lower layers
+			if setOnCommandLine[f.Name] {
+				return nil
+			}
+
+			return setOrRestore(f.Value, value)
+		}
+	})
+
+	for key, target := range envOnly {
+		settings[key] = func(value string) error {
+			*target = value
+			return nil
+		}
+	}
+
+	var problems []string
+
+	if cfg.ConfigFile != "" {
+		fileValues, fileErr := readFile(cfg.ConfigFile)
+
+		if fileErr != nil {
+			problems = append(problems, fileErr.Error())
+		}
+
+		for _, key := range slices.Sorted(maps.Keys(fileValues)) {
+			set, ok := settings[key]
+
+			if !ok {
+				problems = append(problems, fmt.Sprintf("%s: unknown setting %q", cfg.ConfigFile, key))
+				continue
+			}
+
+			if err := set(fileValues[key]); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %s: invalid value %q: %v", cfg.ConfigFile, key, fileValues[key], err))
+			}
+		}
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(settings)) {
+		envName := strings.ToUpper(key)
+		value := os.Getenv(envName)
+
+		// Empty counts as unset, e.g. the blank placeholders in .env.example
+		if value == "" {
+			continue
+		}
+
+		if err := settings[key](value); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: invalid value %q: %v", envName, value, err))
+		}
+	}
+
+	// Reported after the lower layers, in precedence order
+	problems = append(problems, flagProblems...)
 
-	var schedule string
+	cfg.QuoteProviders = parseList(quoteProviders)
 
-	flag.StringVar(&schedule, "schedule", "0 * * * *", "Cron schedule that controls when the reminder is sent (supports standard cron syntax and @every intervals)")
-	flag.StringVar(&schedule, "s", "0 * * * *", "Cron schedule that controls when the reminder is sent (supports standard cron syntax and @every intervals)")
+	chatIDs, chatIDProblems := parseChatIDs(adminChatIDs)
 
-	flag.Parse()
+	cfg.AdminChatIDs = chatIDs
+	problems = append(problems, chatIDProblems...)
 
-	return Config{
-		TelegramToken:       os.Getenv("TG_BOT_TOKEN"),
-		TelegramBaseURL:     os.Getenv("TG_API_BASE_URL"),
-		TelegramPollTimeout: time.Second * 65,
-		QuotesBaseURL:       os.Getenv("QUOTE_API_URL"),
-		Schedule:            schedule,
-		DefaultQuote:        os.Getenv("DEFAULT_QUOTE"),
-		DatabaseURL:         os.Getenv("DATABASE_URL"),
+	problems = append(problems, cfg.validate()...)
+
+	if len(problems) > 0 {
+		return cfg, &ValidationError{Problems: problems}
+	}
+
+	return cfg, nil
+}
+
+// parseFlags parses args into flags, carrying on past bad values so every one of them is reported.
+// Parsing still stops at an unknown flag or a missing value, which is reported as well. -h prints the usage and exits.
+func parseFlags(flags *flag.FlagSet, args []string) []string {
+	var problems []string
+
+	originals := make(map[*flag.Flag]flag.Value)
+
+	flags.VisitAll(func(f *flag.Flag) {
+		originals[f] = f.Value
+		f.Value = recordingValue{Value: f.Value, name: f.Name, problems: &problems}
+	})
+
+	// Errors end up in problems; usage is printed below once the real values are back
+	flags.SetOutput(io.Discard)
+
+	parseErr := flags.Parse(args)
+
+	flags.SetOutput(nil)
+
+	// The file and env layers set flags later, and must get their errors back rather than recorded here
+	for f, value := range originals {
+		f.Value = value
 	}
+
+	if errors.Is(parseErr, flag.ErrHelp) {
+		flags.Usage()
+		os.Exit(0)
+	}
+
+	if parseErr != nil {
+		problems = append(problems, parseErr.Error())
+	}
+
+	return problems
+}
+
+// recordingValue wraps a flag's value while the command line is parsed, recording a bad value in problems
+// instead of failing, so flag.Parse moves on to the next flag.
+type recordingValue struct {
+	flag.Value
+	name     string
+	problems *[]string
+}
+
+func (v recordingValue) Set(value string) error {
+	if err := setOrRestore(v.Value, value); err != nil {
+		*v.problems = append(*v.problems, fmt.Sprintf("--%s: invalid value %q: %v", v.name, value, err))
+	}
+
+	return nil
+}
+
+// IsBoolFlag keeps bool flags usable without a value, e.g. plain --tracing.
+func (v recordingValue) IsBoolFlag() bool {
+	boolValue, ok := v.Value.(interface{ IsBoolFlag() bool })
+
+	return ok && boolValue.IsBoolFlag()
+}
+
+// setOrRestore sets value, putting the previous one back if it's rejected. A failed Set can leave a zero behind,
+// which validate would then report a second time.
+func setOrRestore(target flag.Value, value string) error {
+	previous := target.String()
+
+	if err := target.Set(value); err != nil {
+		target.Set(previous)
+		return err
+	}
+
+	return nil
+}
+
+// parseChatIDs reads a comma-separated list of chat IDs, reporting anything that isn't a number.
+func parseChatIDs(raw string) ([]int64, []string) {
+	var chatIDs []int64
+	var problems []string
+
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.TrimSpace(field)
+
+		if field == "" {
+			continue
+		}
+
+		chatID, err := strconv.ParseInt(field, 10, 64)
+
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("ADMIN_CHAT_IDS: %q is not a chat ID", field))
+			continue
+		}
+
+		chatIDs = append(chatIDs, chatID)
+	}
+
+	return chatIDs, problems
+}
+
+// parseList splits a comma-separated flag value, dropping empty entries.
+func parseList(raw string) []string {
+	var items []string
+
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.ToLower(strings.TrimSpace(field))
+
+		if field != "" {
+			items = append(items, field)
+		}
+	}
+
+	return items
 }
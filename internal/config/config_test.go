@@ -0,0 +1,201 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Every env var the cases below touch. Each is cleared first, so only the case's own env applies.
+var testEnvVars = []string{
+	"CONFIG_FILE", "TG_BOT_TOKEN", "DATABASE_URL", "QUOTE_API_URL", "ADMIN_CHAT_IDS",
+	"SCHEDULE", "WORKERS", "RETRIES", "CATCHUP_WINDOW", "LOG_LEVEL", "TRACING", "QUOTE_PROVIDER",
+}
+
+// Enough for a valid config on its own
+var requiredEnv = map[string]string{
+	"TG_BOT_TOKEN":  "token",
+	"DATABASE_URL":  "postgres://localhost/scheduler",
+	"QUOTE_API_URL": "https://quotes.example.com/api/random",
+}
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		// Config file name and content, written to a temp dir and passed with --config
+		fileName string
+		file     string
+		env      map[string]string
+		args     []string
+		// Substrings of the expected problems, in order. Empty means LoadConfig must succeed.
+		wantProblems []string
+		check        func(t *testing.T, cfg Config)
+	}{
+		{
+			name: "defaults",
+			env:  requiredEnv,
+			check: func(t *testing.T, cfg Config) {
+				if cfg.Schedule != "0 * * * *" || cfg.BroadcastWorkers != 5 || cfg.TelegramPollTimeout != time.Minute {
+					t.Errorf("defaults not applied: %+v", cfg)
+				}
+
+				if cfg.TelegramBaseURL != "https://api.telegram.org/bot" {
+					t.Errorf("TelegramBaseURL = %q, want the public Bot API", cfg.TelegramBaseURL)
+				}
+			},
+		},
+		{
+			name:     "flag beats env beats yaml file",
+			fileName: "scheduler.yaml",
+			file:     "workers: 2\nretries: 1\ncatchup_window: 1h\nlog_level: debug\n",
+			env:      merge(requiredEnv, map[string]string{"WORKERS": "3", "CATCHUP_WINDOW": "2h"}),
+			args:     []string{"-w", "4"},
+			check: func(t *testing.T, cfg Config) {
+				if cfg.BroadcastWorkers != 4 {
+					t.Errorf("BroadcastWorkers = %d, want 4 from the flag", cfg.BroadcastWorkers)
+				}
+
+				if cfg.CatchUpWindow != 2*time.Hour {
+					t.Errorf("CatchUpWindow = %s, want 2h from the env", cfg.CatchUpWindow)
+				}
+
+				if cfg.SendRetries != 1 || cfg.LogLevel != "debug" {
+					t.Errorf("SendRetries = %d, LogLevel = %q, want 1 and debug from the file", cfg.SendRetries, cfg.LogLevel)
+				}
+			},
+		},
+		{
+			name:     "toml file with lists and secrets",
+			fileName: "scheduler.toml",
+			file: `tg_bot_token = "from-file"
+database_url = "postgres://localhost/scheduler"
+quote_provider = ["postgres", "api"]
+admin_chat_ids = [1, 2]
+tracing = true
+`,
+			env: map[string]string{"QUOTE_API_URL": "https://quotes.example.com/api/random", "TG_BOT_TOKEN": "from-env"},
+			check: func(t *testing.T, cfg Config) {
+				if cfg.TelegramToken != "from-env" {
+					t.Errorf("TelegramToken = %q, want from-env", cfg.TelegramToken)
+				}
+
+				if !slices.Equal(cfg.QuoteProviders, []string{"postgres", "api"}) {
+					t.Errorf("QuoteProviders = %v, want [postgres api]", cfg.QuoteProviders)
+				}
+
+				if !slices.Equal(cfg.AdminChatIDs, []int64{1, 2}) || !cfg.Tracing {
+					t.Errorf("AdminChatIDs = %v, Tracing = %v, want [1 2] and true", cfg.AdminChatIDs, cfg.Tracing)
+				}
+			},
+		},
+		{
+			name: "bool flag without a value",
+			env:  merge(requiredEnv, map[string]string{"TRACING": "false"}),
+			args: []string{"--tracing", "migrate"},
+			check: func(t *testing.T, cfg Config) {
+				if !cfg.Tracing || !slices.Equal(cfg.Args, []string{"migrate"}) {
+					t.Errorf("Tracing = %v, Args = %v, want true and [migrate]", cfg.Tracing, cfg.Args)
+				}
+			},
+		},
+		{
+			name:     "every problem reported at once",
+			fileName: "scheduler.yaml",
+			file:     "retries: -1\nbogus: 1\n",
+			env:      map[string]string{"LOG_LEVEL": "loud", "RETRIES": "x", "ADMIN_CHAT_IDS": "1,me"},
+			args:     []string{"--workers=abc", "--schedule", "0 25 * * *", "--poll-timeout", "soon"},
+			wantProblems: []string{
+				`unknown setting "bogus"`,
+				`RETRIES: invalid value "x"`,
+				`--workers: invalid value "abc"`,
+				`--poll-timeout: invalid value "soon"`,
+				`ADMIN_CHAT_IDS: "me" is not a chat ID`,
+				"DATABASE_URL is required",
+				"TG_BOT_TOKEN is required",
+				`--schedule "0 25 * * *"`,
+				"--retries can't be negative, got -1",
+				`--log-level must be debug, info, warn or error, got "loud"`,
+				"QUOTE_API_URL is required",
+			},
+		},
+		{
+			name:         "unknown flag",
+			env:          requiredEnv,
+			args:         []string{"--wokers", "3"},
+			wantProblems: []string{"flag provided but not defined: -wokers"},
+		},
+		{
+			name: "migrate only needs the database",
+			env:  map[string]string{"DATABASE_URL": "postgres://localhost/scheduler", "QUOTE_PROVIDER": "api"},
+			args: []string{"migrate"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range testEnvVars {
+				t.Setenv(name, "")
+			}
+
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			args := tt.args
+
+			if tt.fileName != "" {
+				path := filepath.Join(t.TempDir(), tt.fileName)
+
+				if err := os.WriteFile(path, []byte(tt.file), 0o600); err != nil {
+					t.Fatal(err)
+				}
+
+				args = append([]string{"--config", path}, args...)
+			}
+
+			cfg, err := LoadConfig(args)
+
+			if len(tt.wantProblems) == 0 {
+				if err != nil {
+					t.Fatalf("LoadConfig: %v", err)
+				}
+			} else {
+				var validationErr *ValidationError
+
+				if !errors.As(err, &validationErr) {
+					t.Fatalf("LoadConfig error = %v, want a *ValidationError", err)
+				}
+
+				if len(validationErr.Problems) != len(tt.wantProblems) {
+					t.Fatalf("got %d problems, want %d:\n%s", len(validationErr.Problems), len(tt.wantProblems), strings.Join(validationErr.Problems, "\n"))
+				}
+
+				for i, want := range tt.wantProblems {
+					if !strings.Contains(validationErr.Problems[i], want) {
+						t.Errorf("problem %d = %q, want it to contain %q", i, validationErr.Problems[i], want)
+					}
+				}
+			}
+
+			if tt.check != nil {
+				tt.check(t, cfg)
+			}
+		})
+	}
+}
+
+func merge(envs ...map[string]string) map[string]string {
+	merged := make(map[string]string)
+
+	for _, m := range envs {
+		for k, v := range m {
+			merged[k] = v
+		}
+	}
+
+	return merged
+}
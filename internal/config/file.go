@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"go.yaml.in/yaml/v3"
+)
+
+// readFile loads a flat YAML (.yaml, .yml) or TOML (.toml) config file as setting key → value, in the same
+// string form the flags take. Lists, e.g. quote_provider or admin_chat_ids, are joined with commas.
+func readFile(path string) (map[string]string, error) {
+	content, readErr := os.ReadFile(path)
+
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	raw := make(map[string]any)
+
+	var decodeErr error
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decodeErr = yaml.Unmarshal(content, &raw)
+	case ".toml":
+		decodeErr = toml.Unmarshal(content, &raw)
+	default:
+		return nil, fmt.Errorf("%s: unsupported config file type (expected .yaml, .yml or .toml)", path)
+	}
+
+	if decodeErr != nil {
+		return nil, fmt.Errorf("%s: %w", path, decodeErr)
+	}
+
+	values := make(map[string]string, len(raw))
+
+	for key, value := range raw {
+		switch typed := value.(type) {
+		case map[string]any:
+			return nil, fmt.Errorf("%s: %s: nested tables aren't supported, settings are top-level keys", path, key)
+		case []any:
+			items := make([]string, len(typed))
+
+			for i, item := range typed {
+				items[i] = fmt.Sprint(item)
+			}
+
+			values[key] = strings.Join(items, ",")
+		case nil:
+			values[key] = ""
+		default:
+			values[key] = fmt.Sprint(typed)
+		}
+	}
+
+	return values, nil
+}
@@ -0,0 +1,182 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// The longest --poll-timeout allowed, so a healthy long poll always lands well inside the /healthz staleness window
+const maxPollTimeout = 2 * time.Minute
+
+// ValidationError is every problem LoadConfig found, so they can all be fixed in one go.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return strings.Join(e.Problems, "; ")
+}
+
+// validate checks every field and returns one message per problem, naming the setting by its flag or env var.
+func (cfg Config) validate() []string {
+	var problems []string
+
+	fail := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if cfg.DatabaseURL == "" {
+		fail("DATABASE_URL is required")
+	}
+
+	// migrate and quotes import only talk to the database
+	if len(cfg.Args) > 0 && (cfg.Args[0] == "migrate" || cfg.Args[0] == "quotes") {
+		return problems
+	}
+
+	if cfg.TelegramToken == "" {
+		fail("TG_BOT_TOKEN is required")
+	}
+
+	if err := checkURL(cfg.TelegramBaseURL, false); err != nil {
+		fail("TG_API_BASE_URL: %v", err)
+	}
+
+	if _, err := cron.ParseStandard(cfg.Schedule); err != nil {
+		fail("--schedule %q: %v", cfg.Schedule, err)
+	}
+
+	if cfg.BroadcastWorkers < 1 {
+		fail("--workers must be at least 1, got %d", cfg.BroadcastWorkers)
+	}
+
+	if cfg.SendRetries < 0 {
+		fail("--retries can't be negative, got %d", cfg.SendRetries)
+	}
+
+	if cfg.CatchUpWindow < 0 {
+		fail("--catchup-window can't be negative, got %s", cfg.CatchUpWindow)
+	}
+
+	if cfg.LeaderRetryInterval <= 0 {
+		fail("--leader-retry must be positive, got %s", cfg.LeaderRetryInterval)
+	}
+
+	if cfg.ConfigRefresh < 0 {
+		fail("--config-refresh can't be negative, got %s", cfg.ConfigRefresh)
+	}
+
+	if cfg.TelegramPollTimeout < time.Second || cfg.TelegramPollTimeout > maxPollTimeout {
+		fail("--poll-timeout must be between 1s and %s, got %s", maxPollTimeout, cfg.TelegramPollTimeout)
+	}
+
+	if cfg.SendTimeout <= 0 {
+		fail("--send-timeout must be positive, got %s", cfg.SendTimeout)
+	}
+
+	switch cfg.UpdateMode {
+	case "polling":
+	case "webhook":
+		// Telegram only delivers webhooks over HTTPS
+		if cfg.WebhookURL == "" {
+			fail("TG_WEBHOOK_URL is required with --update-mode webhook")
+		} else if err := checkURL(cfg.WebhookURL, true); err != nil {
+			fail("TG_WEBHOOK_URL: %v", err)
+		}
+
+		if err := checkListenAddr(cfg.WebhookListenAddr); err != nil {
+			fail("--webhook-addr: %v", err)
+		}
+	default:
+		fail("--update-mode must be \"polling\" or \"webhook\", got %q", cfg.UpdateMode)
+	}
+
+	if cfg.MetricsListenAddr != "" {
+		if err := checkListenAddr(cfg.MetricsListenAddr); err != nil {
+			fail("--metrics-addr: %v", err)
+		}
+	}
+
+	if cfg.LogFormat != "text" && cfg.LogFormat != "json" {
+		fail("--log-format must be \"text\" or \"json\", got %q", cfg.LogFormat)
+	}
+
+	var logLevel slog.Level
+
+	if err := logLevel.UnmarshalText([]byte(cfg.LogLevel)); err != nil {
+		fail("--log-level must be debug, info, warn or error, got %q", cfg.LogLevel)
+	}
+
+	if len(cfg.QuoteProviders) == 0 {
+		fail("--quote-provider needs at least one provider")
+	}
+
+	for _, name := range cfg.QuoteProviders {
+		if !slices.Contains([]string{"api", "file", "postgres"}, name) {
+			fail("--quote-provider: unknown provider %q (expected api, file or postgres)", name)
+		}
+	}
+
+	if slices.Contains(cfg.QuoteProviders, "api") {
+		if cfg.QuotesBaseURL == "" {
+			fail("QUOTE_API_URL is required by the api quote provider")
+		} else if err := checkURL(cfg.QuotesBaseURL, false); err != nil {
+			fail("QUOTE_API_URL: %v", err)
+		}
+
+		if cfg.QuoteTimeout <= 0 {
+			fail("--quote-timeout must be positive, got %s", cfg.QuoteTimeout)
+		}
+
+		if cfg.QuoteBreakerThreshold < 0 {
+			fail("--quote-breaker-threshold can't be negative, got %d", cfg.QuoteBreakerThreshold)
+		}
+
+		if cfg.QuoteBreakerThreshold > 0 && cfg.QuoteBreakerCooldown <= 0 {
+			fail("--quote-breaker-cooldown must be positive while the breaker is enabled, got %s", cfg.QuoteBreakerCooldown)
+		}
+	}
+
+	if slices.Contains(cfg.QuoteProviders, "file") && cfg.QuoteFile == "" {
+		fail("--quote-file is required by the file quote provider")
+	}
+
+	return problems
+}
+
+// checkURL reports whether raw is an absolute http(s) URL, or https only when httpsOnly is set.
+func checkURL(raw string, httpsOnly bool) error {
+	parsed, err := url.Parse(raw)
+
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case httpsOnly && parsed.Scheme != "https":
+		return fmt.Errorf("%q must be an https URL", raw)
+	case parsed.Scheme != "http" && parsed.Scheme != "https":
+		return fmt.Errorf("%q must be an http or https URL", raw)
+	}
+
+	if parsed.Host == "" {
+		return fmt.Errorf("%q has no host", raw)
+	}
+
+	return nil
+}
+
+func checkListenAddr(addr string) error {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return fmt.Errorf("%q: %w", addr, err)
+	}
+
+	return nil
+}
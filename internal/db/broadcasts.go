@@ -0,0 +1,136 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+)
+
+// Values stored in broadcast_deliveries.status
+const (
+	DeliverySent      = "sent"
+	DeliveryFailed    = "failed"
+	DeliveryPermanent = "permanent"
+)
+
+// Values stored in broadcast_runs.kind: runs fired by a job (including catch-up), and admin /broadcast runs
+const (
+	RunScheduled = "scheduled"
+	RunAdhoc     = "adhoc"
+)
+
+// StartBroadcastRun records a new run and returns its id, so deliveries can be attached to it.
+func StartBroadcastRun(ctx context.Context, pgDB *sql.DB, kind string, firedAt time.Time, quote string, targetCount int) (int64, error) {
+	query := `
+		INSERT INTO broadcast_runs (kind, fired_at, quote, target_count)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+
+	var runID int64
+
+	err := pgDB.QueryRowContext(ctx, query, kind, firedAt, quote, targetCount).Scan(&runID)
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Error recording broadcast run", "error", err)
+		return 0, err
+	}
+
+	return runID, nil
+}
+
+func FinishBroadcastRun(ctx context.Context, pgDB *sql.DB, runID int64, success int, failure int, duration time.Duration) error {
+	query := `
+		UPDATE broadcast_runs
+		SET success_count = $1,
+			failure_count = $2,
+			duration_ms = $3,
+			finished_at = NOW()
+		WHERE id = $4
+	`
+
+	_, err := pgDB.ExecContext(ctx, query, success, failure, duration.Milliseconds(), runID)
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Error finishing broadcast run", "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// AddBroadcastDelivery records the outcome of a single send. sendErr is stored as-is, nil for successful sends.
+// quoteID is the cached quote that was sent, or 0 for messages that aren't in the quotes table.
+func AddBroadcastDelivery(ctx context.Context, pgDB *sql.DB, runID int64, chatID int64, quoteID int64, status string, sendErr error) error {
+	query := `
+		INSERT INTO broadcast_deliveries (run_id, chat_id, quote_id, status, error)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	var errorText sql.NullString
+
+	if sendErr != nil {
+		errorText = sql.NullString{String: sendErr.Error(), Valid: true}
+	}
+
+	quoteIDValue := sql.NullInt64{Int64: quoteID, Valid: quoteID != 0}
+
+	_, err := pgDB.ExecContext(ctx, query, runID, chatID, quoteIDValue, status, errorText)
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Error recording broadcast delivery", "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// ClaimDailyDelivery reserves today's quote for a user, where "today" is the user's local date at nowUTC.
+// It returns false if the user already has a delivery for that date, so concurrent or repeated runs never send twice.
+func ClaimDailyDelivery(ctx context.Context, pgDB *sql.DB, chatID int64, nowUTC time.Time) (bool, error) {
+	query := `
+		INSERT INTO daily_deliveries (chat_id, local_date)
+		SELECT chat_id, ($2 AT TIME ZONE COALESCE(timezone, 'UTC'))::date
+		FROM users
+		WHERE chat_id = $1
+		ON CONFLICT (chat_id, local_date) DO NOTHING
+	`
+
+	result, err := pgDB.ExecContext(ctx, query, chatID, nowUTC)
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Error claiming daily delivery", "error", err)
+		return false, err
+	}
+
+	claimed, err := result.RowsAffected()
+
+	if err != nil {
+		return false, err
+	}
+
+	return claimed == 1, nil
+}
+
+// ReleaseDailyDelivery drops a claim whose send failed, so a later run that day can try again.
+func ReleaseDailyDelivery(ctx context.Context, pgDB *sql.DB, chatID int64, nowUTC time.Time) error {
+	query := `
+		DELETE FROM daily_deliveries
+		WHERE chat_id = $1
+			AND local_date = (
+				SELECT ($2 AT TIME ZONE COALESCE(timezone, 'UTC'))::date
+				FROM users
+				WHERE chat_id = $1
+			)
+	`
+
+	_, err := pgDB.ExecContext(ctx, query, chatID, nowUTC)
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Error releasing daily delivery", "error", err)
+		return err
+	}
+
+	return nil
+}
@@ -4,7 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"log"
+	"log/slog"
 	"strconv"
 )
 
@@ -21,7 +21,7 @@ func GetTelegramOffset(ctx context.Context, pgDB *sql.DB) (int, error) {
 
 	if sqlRowErr := row.Scan(&rawValue); sqlRowErr != nil {
 		if errors.Is(sqlRowErr, sql.ErrNoRows) {
-			log.Println("⚠️ [WARNING] bot_config row for telegram_offset is missing. Insert it with: INSERT INTO bot_config (key, value) VALUES ('telegram_offset', '0'); — offset persistence will not work until this is fixed.")
+			slog.WarnContext(ctx, "bot_config row for telegram_offset is missing. It is seeded by migration 002 — check schema_migrations, or re-insert it with: INSERT INTO bot_config (key, value) VALUES ('telegram_offset', '0'); — offset persistence will not work until this is fixed.")
 			return 0, nil
 		}
 
@@ -50,7 +50,7 @@ func GetSendHour(ctx context.Context, pgDB *sql.DB) (int64, error) {
 
 	if sqlRowErr := row.Scan(&rawValue); sqlRowErr != nil {
 		if errors.Is(sqlRowErr, sql.ErrNoRows) {
-			log.Println("⚠️ [WARNING] bot_config row for send_hour is missing. Insert it with: INSERT INTO bot_config (key, value) VALUES ('send_hour', '9'); — Quotes might be sent at inappropriate hours for some users.")
+			slog.WarnContext(ctx, "bot_config row for send_hour is missing. It is seeded by migration 002 — check schema_migrations, or re-insert it with: INSERT INTO bot_config (key, value) VALUES ('send_hour', '9'); — Quotes might be sent at inappropriate hours for some users.")
 			return 0, nil
 		}
 
@@ -81,3 +81,37 @@ func UpdateBotConfig(ctx context.Context, pgDB *sql.DB, key string, value int) e
 
 	return nil
 }
+
+// SetLeaderMachine records the Fly machine ID of the instance that just became leader, so standby instances can send
+// webhook requests on to it.
+func SetLeaderMachine(ctx context.Context, pgDB *sql.DB, machineID string) error {
+	query := `
+		INSERT INTO bot_config (key, value)
+		VALUES ('leader_machine_id', $1)
+		ON CONFLICT (key) DO UPDATE
+		SET value = $1;
+	`
+
+	_, err := pgDB.ExecContext(ctx, query, machineID)
+
+	return err
+}
+
+// GetLeaderMachine returns the machine ID stored by SetLeaderMachine, empty if no leader has stored one.
+func GetLeaderMachine(ctx context.Context, pgDB *sql.DB) (string, error) {
+	query := `
+		SELECT value
+		FROM bot_config
+		WHERE key = 'leader_machine_id';
+	`
+
+	var machineID string
+
+	err := pgDB.QueryRowContext(ctx, query).Scan(&machineID)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+
+	return machineID, err
+}
@@ -0,0 +1,105 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// GetRandomCuratedQuote picks a random quote from the curated_quotes list. A non-empty category limits the pick
+// to that category. ok is false when nothing matches.
+func GetRandomCuratedQuote(ctx context.Context, pgDB *sql.DB, category string) (Quote, bool, error) {
+	query := `
+		SELECT id, text, author, category
+		FROM curated_quotes
+		WHERE $1 = '' OR category = $1
+		ORDER BY random()
+		LIMIT 1
+	`
+
+	var q Quote
+
+	err := pgDB.QueryRowContext(ctx, query, category).Scan(&q.ID, &q.Text, &q.Author, &q.Category)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return Quote{}, false, nil
+	}
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Error picking curated quote", "error", err)
+		return Quote{}, false, err
+	}
+
+	return q, true, nil
+}
+
+// PickUnusedCuratedQuote picks a random curated quote the user hasn't been sent yet, preferring ones in category.
+// Quotes already sent are found through their "curated:<id>" row in the quotes cache. ok is false once the user has seen them all.
+func PickUnusedCuratedQuote(ctx context.Context, pgDB *sql.DB, chatID int64, category string) (Quote, bool, error) {
+	query := `
+		SELECT c.id, c.text, c.author, c.category
+		FROM curated_quotes c
+		WHERE NOT EXISTS (
+			SELECT 1
+			FROM user_quotes uq
+			JOIN quotes q ON q.id = uq.quote_id
+			WHERE uq.chat_id = $1 AND q.api_id = 'curated:' || c.id
+		)
+		ORDER BY (c.category = $2) DESC, random()
+		LIMIT 1
+	`
+
+	var q Quote
+
+	err := pgDB.QueryRowContext(ctx, query, chatID, category).Scan(&q.ID, &q.Text, &q.Author, &q.Category)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return Quote{}, false, nil
+	}
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Error picking unused curated quote", "error", err)
+		return Quote{}, false, err
+	}
+
+	return q, true, nil
+}
+
+// ImportCuratedQuotes adds quotes to curated_quotes in one transaction, skipping any whose text and author
+// are already there. It returns how many were actually inserted.
+func ImportCuratedQuotes(ctx context.Context, pgDB *sql.DB, quotes []Quote) (int, error) {
+	tx, err := pgDB.BeginTx(ctx, nil)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO curated_quotes (text, author, category)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (md5(text), author) DO NOTHING
+	`
+
+	var inserted int
+
+	for i, q := range quotes {
+		result, execErr := tx.ExecContext(ctx, query, q.Text, q.Author, q.Category)
+
+		if execErr != nil {
+			return 0, fmt.Errorf("importing quote %d: %w", i+1, execErr)
+		}
+
+		rows, _ := result.RowsAffected()
+		inserted += int(rows)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return inserted, nil
+}
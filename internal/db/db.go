@@ -3,27 +3,39 @@ package db
 import (
 	"context"
 	"database/sql"
-	"log"
+	"log/slog"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/sriram651/go-scheduler/internal/logging"
+	"github.com/sriram651/go-scheduler/internal/tracing"
 )
 
+var tracer = tracing.Tracer("db")
+
 func Connect(dbURL string) *sql.DB {
-	log.Println("⏳Connecting to Postgres database...")
+	slog.Info("Connecting to Postgres database")
 	pgDB, err := sql.Open("pgx", dbURL)
 
 	if err != nil {
-		log.Fatalln("❌ Connection to Postgres failed:", err)
+		logging.Fatal("Connection to Postgres failed", "error", err)
 	}
 
-	log.Println("⏳Pinging Postgres database...")
+	slog.Info("Pinging Postgres database")
 	pingErr := pgDB.PingContext(context.Background())
 
 	if pingErr != nil {
-		log.Fatalln("❌ Ping to Postgres failed:", pingErr)
+		logging.Fatal("Ping to Postgres failed", "error", pingErr)
+	}
+
+	slog.Info("Connection to Postgres database established")
+
+	slog.Info("Applying database migrations")
+
+	if migrateErr := Migrate(context.Background(), pgDB); migrateErr != nil {
+		logging.Fatal("Database migrations failed", "error", migrateErr)
 	}
 
-	log.Println("✅ Connection to Postgres database established")
+	slog.Info("Database schema is up to date")
 
 	return pgDB
 }
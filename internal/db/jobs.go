@@ -0,0 +1,128 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+)
+
+// Job is a row of the jobs table: a named cron schedule bound to one of the handlers the app registers.
+type Job struct {
+	Name     string `json:"name"`
+	Schedule string `json:"schedule"`
+	Handler  string `json:"handler"`
+	Enabled  bool   `json:"enabled"`
+	Overlap  string `json:"overlap"`
+}
+
+// Values stored in job_runs.status
+const (
+	JobRunRunning = "running"
+	JobRunOK      = "ok"
+	JobRunFailed  = "failed"
+)
+
+func GetJobs(ctx context.Context, pgDB *sql.DB) ([]Job, error) {
+	query := `
+		SELECT name, schedule, handler, enabled, overlap
+		FROM jobs
+		ORDER BY name;
+	`
+
+	rows, err := pgDB.QueryContext(ctx, query)
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Error loading jobs", "error", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var jobs []Job
+
+	for rows.Next() {
+		var job Job
+
+		if err := rows.Scan(&job.Name, &job.Schedule, &job.Handler, &job.Enabled, &job.Overlap); err != nil {
+			slog.ErrorContext(ctx, "Error loading jobs", "error", err)
+			return nil, err
+		}
+
+		jobs = append(jobs, job)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(ctx, "Error loading jobs", "error", err)
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+func StartJobRun(ctx context.Context, pgDB *sql.DB, jobName string, firedAt time.Time) (int64, error) {
+	query := `
+		INSERT INTO job_runs (job_name, fired_at)
+		VALUES ($1, $2)
+		RETURNING id
+	`
+
+	var runID int64
+
+	err := pgDB.QueryRowContext(ctx, query, jobName, firedAt).Scan(&runID)
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Error recording job run", "error", err)
+		return 0, err
+	}
+
+	return runID, nil
+}
+
+// GetLastJobRunTime returns the fire time of the job's most recent run that finished, failed or not. ok is false if none ever did.
+func GetLastJobRunTime(ctx context.Context, pgDB *sql.DB, jobName string) (time.Time, bool, error) {
+	query := `
+		SELECT MAX(fired_at)
+		FROM job_runs
+		WHERE job_name = $1
+			AND finished_at IS NOT NULL
+	`
+
+	var firedAt sql.NullTime
+
+	if err := pgDB.QueryRowContext(ctx, query, jobName).Scan(&firedAt); err != nil {
+		return time.Time{}, false, err
+	}
+
+	return firedAt.Time, firedAt.Valid, nil
+}
+
+// FinishJobRun marks a job run as ok, or failed with runErr's message if it is non-nil.
+func FinishJobRun(ctx context.Context, pgDB *sql.DB, runID int64, runErr error, duration time.Duration) error {
+	query := `
+		UPDATE job_runs
+		SET status = $1,
+			error = $2,
+			duration_ms = $3,
+			finished_at = NOW()
+		WHERE id = $4
+	`
+
+	status := JobRunOK
+
+	var errorText sql.NullString
+
+	if runErr != nil {
+		status = JobRunFailed
+		errorText = sql.NullString{String: runErr.Error(), Valid: true}
+	}
+
+	_, err := pgDB.ExecContext(ctx, query, status, errorText, duration.Milliseconds(), runID)
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Error finishing job run", "error", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+// AdvisoryLock is a session-level Postgres advisory lock. It lives on its own pooled connection,
+// since the lock is released as soon as that session ends.
+type AdvisoryLock struct {
+	conn *sql.Conn
+	key  int64
+}
+
+// TryAdvisoryLock attempts pg_try_advisory_lock(key) without blocking. It returns nil, nil if another session holds the lock.
+func TryAdvisoryLock(ctx context.Context, pgDB *sql.DB, key int64) (*AdvisoryLock, error) {
+	conn, err := pgDB.Conn(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var acquired bool
+
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&acquired); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if !acquired {
+		conn.Close()
+		return nil, nil
+	}
+
+	return &AdvisoryLock{conn: conn, key: key}, nil
+}
+
+// Ping checks that the session holding the lock is still alive. An error means the lock may have been lost.
+func (l *AdvisoryLock) Ping(ctx context.Context) error {
+	return l.conn.PingContext(ctx)
+}
+
+// Release unlocks and hands the connection back to the pool.
+func (l *AdvisoryLock) Release(ctx context.Context) error {
+	_, unlockErr := l.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", l.key)
+
+	closeErr := l.conn.Close()
+
+	if unlockErr != nil {
+		return unlockErr
+	}
+
+	return closeErr
+}
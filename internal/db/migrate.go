@@ -0,0 +1,163 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Serializes migrations across instances starting at the same time ("migrate" in ASCII)
+const migrationLockKey int64 = 0x6d696772617465
+
+type migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// Migrate applies every embedded migration that isn't recorded in schema_migrations yet, each in its own transaction.
+// Files are named NNN_description.sql and applied in version order.
+func Migrate(ctx context.Context, pgDB *sql.DB) error {
+	migrations, loadErr := loadMigrations()
+
+	if loadErr != nil {
+		return loadErr
+	}
+
+	conn, connErr := pgDB.Conn(ctx)
+
+	if connErr != nil {
+		return connErr
+	}
+
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockKey); err != nil {
+		return err
+	}
+
+	defer conn.ExecContext(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", migrationLockKey)
+
+	createTableQuery := `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    INT         PRIMARY KEY,
+			name       TEXT        NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+	`
+
+	if _, err := conn.ExecContext(ctx, createTableQuery); err != nil {
+		return err
+	}
+
+	applied, appliedErr := appliedMigrations(ctx, conn)
+
+	if appliedErr != nil {
+		return appliedErr
+	}
+
+	for _, m := range migrations {
+		if applied[m.Version] {
+			continue
+		}
+
+		if err := applyMigration(ctx, conn, m); err != nil {
+			return fmt.Errorf("migration %03d_%s failed: %w", m.Version, m.Name, err)
+		}
+
+		slog.InfoContext(ctx, "Applied migration", "migration", fmt.Sprintf("%03d_%s", m.Version, m.Name))
+	}
+
+	return nil
+}
+
+func loadMigrations() ([]migration, error) {
+	entries, readErr := fs.ReadDir(migrationFiles, "migrations")
+
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	var migrations []migration
+
+	for _, entry := range entries {
+		fileName := entry.Name()
+
+		rawVersion, name, found := strings.Cut(strings.TrimSuffix(fileName, ".sql"), "_")
+
+		if !found {
+			return nil, fmt.Errorf("migration file %s is not named NNN_description.sql", fileName)
+		}
+
+		version, convErr := strconv.Atoi(rawVersion)
+
+		if convErr != nil {
+			return nil, fmt.Errorf("migration file %s has no numeric version: %w", fileName, convErr)
+		}
+
+		contents, fileErr := migrationFiles.ReadFile("migrations/" + fileName)
+
+		if fileErr != nil {
+			return nil, fileErr
+		}
+
+		migrations = append(migrations, migration{Version: version, Name: name, SQL: string(contents)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+func appliedMigrations(ctx context.Context, conn *sql.Conn) (map[int]bool, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version FROM schema_migrations")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	applied := make(map[int]bool)
+
+	for rows.Next() {
+		var version int
+
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+
+		applied[version] = true
+	}
+
+	return applied, rows.Err()
+}
+
+func applyMigration(ctx context.Context, conn *sql.Conn, m migration) error {
+	tx, txErr := conn.BeginTx(ctx, nil)
+
+	if txErr != nil {
+		return txErr
+	}
+
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, m.SQL); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", m.Version, m.Name); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS users (
+    chat_id    BIGINT  PRIMARY KEY,
+    first_name TEXT    NOT NULL,
+    username   TEXT,
+    subscribed BOOLEAN NOT NULL DEFAULT false
+);
+
+-- Databases set up from the original DEPLOY.md steps predate this column
+ALTER TABLE users ADD COLUMN IF NOT EXISTS timezone TEXT;
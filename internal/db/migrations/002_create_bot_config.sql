@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS bot_config (
+    key   TEXT PRIMARY KEY,
+    value TEXT NOT NULL
+);
+
+INSERT INTO bot_config (key, value) VALUES ('telegram_offset', '0') ON CONFLICT (key) DO NOTHING;
+INSERT INTO bot_config (key, value) VALUES ('send_hour', '9') ON CONFLICT (key) DO NOTHING;
@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS send_hour SMALLINT CHECK (send_hour BETWEEN 0 AND 23);
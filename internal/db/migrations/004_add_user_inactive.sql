@@ -0,0 +1,2 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS inactive_reason TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS inactive_at TIMESTAMPTZ;
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS broadcast_runs (
+    id            BIGSERIAL   PRIMARY KEY,
+    fired_at      TIMESTAMPTZ NOT NULL,
+    quote         TEXT        NOT NULL,
+    target_count  INT         NOT NULL DEFAULT 0,
+    success_count INT         NOT NULL DEFAULT 0,
+    failure_count INT         NOT NULL DEFAULT 0,
+    duration_ms   BIGINT,
+    finished_at   TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS broadcast_deliveries (
+    id           BIGSERIAL   PRIMARY KEY,
+    run_id       BIGINT      NOT NULL REFERENCES broadcast_runs (id) ON DELETE CASCADE,
+    chat_id      BIGINT      NOT NULL,
+    status       TEXT        NOT NULL,
+    error        TEXT,
+    delivered_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS broadcast_deliveries_chat_id_idx ON broadcast_deliveries (chat_id);
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS daily_deliveries (
+    chat_id    BIGINT      NOT NULL,
+    local_date DATE        NOT NULL,
+    claimed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (chat_id, local_date)
+);
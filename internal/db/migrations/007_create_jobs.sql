@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS jobs (
+    name     TEXT    PRIMARY KEY,
+    schedule TEXT    NOT NULL,
+    handler  TEXT    NOT NULL,
+    enabled  BOOLEAN NOT NULL DEFAULT true
+);
+
+CREATE TABLE IF NOT EXISTS job_runs (
+    id          BIGSERIAL   PRIMARY KEY,
+    job_name    TEXT        NOT NULL,
+    fired_at    TIMESTAMPTZ NOT NULL,
+    status      TEXT        NOT NULL DEFAULT 'running',
+    error       TEXT,
+    duration_ms BIGINT,
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS job_runs_job_name_fired_at_idx ON job_runs (job_name, fired_at DESC);
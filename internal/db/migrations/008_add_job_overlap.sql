@@ -0,0 +1,2 @@
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS overlap TEXT NOT NULL DEFAULT 'skip'
+    CHECK (overlap IN ('skip', 'delay', 'allow'));
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS quotes (
+    id         BIGSERIAL   PRIMARY KEY,
+    api_id     TEXT        UNIQUE,
+    text       TEXT        NOT NULL,
+    author     TEXT        NOT NULL DEFAULT '',
+    fetched_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS user_quotes (
+    chat_id  BIGINT      NOT NULL,
+    quote_id BIGINT      NOT NULL REFERENCES quotes (id) ON DELETE CASCADE,
+    sent_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (chat_id, quote_id)
+);
+
+ALTER TABLE broadcast_deliveries ADD COLUMN IF NOT EXISTS quote_id BIGINT REFERENCES quotes (id) ON DELETE SET NULL;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS curated_quotes (
+    id         BIGSERIAL   PRIMARY KEY,
+    text       TEXT        NOT NULL,
+    author     TEXT        NOT NULL DEFAULT '',
+    category   TEXT        NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS curated_quotes_category_idx ON curated_quotes (category);
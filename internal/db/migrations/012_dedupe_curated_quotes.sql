@@ -0,0 +1,7 @@
+DELETE FROM curated_quotes a
+USING curated_quotes b
+WHERE a.id > b.id
+    AND md5(a.text) = md5(b.text)
+    AND a.author = b.author;
+
+CREATE UNIQUE INDEX IF NOT EXISTS curated_quotes_text_author_idx ON curated_quotes (md5(text), author);
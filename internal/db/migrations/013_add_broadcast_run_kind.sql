@@ -0,0 +1,4 @@
+-- Ad-hoc /broadcast runs must not count as a completed hour for catch-up.
+-- Rows from before this migration can't be told apart and stay 'scheduled'.
+ALTER TABLE broadcast_runs ADD COLUMN IF NOT EXISTS kind TEXT NOT NULL DEFAULT 'scheduled'
+    CHECK (kind IN ('scheduled', 'adhoc'));
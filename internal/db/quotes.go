@@ -0,0 +1,92 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+)
+
+// Quote is a row of the quotes cache. APIID is the quote API's own id, empty for quotes that didn't come from the API.
+// Category is empty for quotes fetched without one.
+type Quote struct {
+	ID       int64  `json:"id"`
+	APIID    string `json:"api_id,omitempty"`
+	Text     string `json:"text"`
+	Author   string `json:"author"`
+	Category string `json:"category,omitempty"`
+}
+
+// SaveQuote caches a quote fetched from the API and returns its row id. Fetching the same API id again returns the existing row.
+// An existing category is never changed, only filled in if the row had none.
+func SaveQuote(ctx context.Context, pgDB *sql.DB, apiID string, text string, author string, category string) (int64, error) {
+	query := `
+		INSERT INTO quotes (api_id, text, author, category)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (api_id) DO UPDATE
+		SET text = $2,
+			author = $3,
+			category = CASE WHEN quotes.category = '' THEN $4 ELSE quotes.category END
+		RETURNING id
+	`
+
+	var quoteID int64
+
+	err := pgDB.QueryRowContext(ctx, query, apiID, text, author, category).Scan(&quoteID)
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Error caching quote", "error", err)
+		return 0, err
+	}
+
+	return quoteID, nil
+}
+
+// PickUnseenQuote returns a cached quote the user hasn't been sent yet, preferring preferredID (e.g. the quote
+// just fetched for this run) and otherwise picking at random. A non-empty category limits the pick to that category.
+// ok is false once the user has seen every matching cached quote.
+func PickUnseenQuote(ctx context.Context, pgDB *sql.DB, chatID int64, preferredID int64, category string) (Quote, bool, error) {
+	query := `
+		SELECT q.id, COALESCE(q.api_id, ''), q.text, q.author, q.category
+		FROM quotes q
+		WHERE ($3 = '' OR q.category = $3)
+			AND NOT EXISTS (
+				SELECT 1 FROM user_quotes uq WHERE uq.chat_id = $1 AND uq.quote_id = q.id
+			)
+		ORDER BY (q.id = $2) DESC, random()
+		LIMIT 1
+	`
+
+	var q Quote
+
+	err := pgDB.QueryRowContext(ctx, query, chatID, preferredID, category).Scan(&q.ID, &q.APIID, &q.Text, &q.Author, &q.Category)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return Quote{}, false, nil
+	}
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Error picking unseen quote", "error", err)
+		return Quote{}, false, err
+	}
+
+	return q, true, nil
+}
+
+// RecordUserQuote marks a quote as sent to a user, so PickUnseenQuote won't offer it again.
+func RecordUserQuote(ctx context.Context, pgDB *sql.DB, chatID int64, quoteID int64) error {
+	query := `
+		INSERT INTO user_quotes (chat_id, quote_id)
+		VALUES ($1, $2)
+		ON CONFLICT (chat_id, quote_id) DO NOTHING
+	`
+
+	_, err := pgDB.ExecContext(ctx, query, chatID, quoteID)
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Error recording user quote", "error", err)
+		return err
+	}
+
+	return nil
+}
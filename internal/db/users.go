@@ -3,8 +3,13 @@ package db
 import (
 	"context"
 	"database/sql"
-	"log"
+	"errors"
+	"log/slog"
 	"time"
+
+	"github.com/sriram651/go-scheduler/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type User struct {
@@ -14,17 +19,94 @@ type User struct {
 	Subscribed bool   `json:"subscribed"`
 }
 
-func GetSubscribedUsersForHour(ctx context.Context, pgDB *sql.DB, nowUTC time.Time, sendHour int) ([]int64, error) {
+// Recipient is a subscriber due a quote, with their preferred quote category ("" for any).
+type Recipient struct {
+	ChatID   int64  `json:"chat_id"`
+	Category string `json:"category,omitempty"`
+}
+
+func GetSubscribedUsersForHour(ctx context.Context, pgDB *sql.DB, nowUTC time.Time, sendHour int) ([]Recipient, error) {
+	ctx, span := tracer.Start(ctx, "db.GetSubscribedUsersForHour", trace.WithAttributes(
+		attribute.String("fired_at", nowUTC.Format(time.RFC3339)),
+		attribute.Int("send_hour", sendHour),
+	))
+
+	recipients, err := getSubscribedUsersForHour(ctx, pgDB, nowUTC, sendHour)
+
+	span.SetAttributes(attribute.Int("recipients", len(recipients)))
+	tracing.End(span, err)
+
+	return recipients, err
+}
+
+func getSubscribedUsersForHour(ctx context.Context, pgDB *sql.DB, nowUTC time.Time, sendHour int) ([]Recipient, error) {
 	query := `
-		SELECT chat_id
+		SELECT chat_id, COALESCE(category, '')
 		FROM users
-		WHERE subscribed=true AND EXTRACT(HOUR FROM $1 AT TIME ZONE COALESCE(timezone, 'UTC'))=$2;
+		WHERE subscribed=true AND EXTRACT(HOUR FROM $1 AT TIME ZONE COALESCE(timezone, 'UTC'))=COALESCE(send_hour, $2);
 	`
 
 	rows, err := pgDB.QueryContext(ctx, query, nowUTC, sendHour)
 
 	if err != nil {
-		log.Println(err)
+		slog.ErrorContext(ctx, "Error loading subscribed users for hour", "error", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var recipients []Recipient
+
+	for rows.Next() {
+		var recipient Recipient
+
+		if err := rows.Scan(&recipient.ChatID, &recipient.Category); err != nil {
+			slog.ErrorContext(ctx, "Error loading subscribed users for hour", "error", err)
+			return nil, err
+		}
+
+		recipients = append(recipients, recipient)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(ctx, "Error loading subscribed users for hour", "error", err)
+		return nil, err
+	}
+
+	return recipients, nil
+}
+
+// CountSubscribedUsers returns how many users are currently subscribed.
+func CountSubscribedUsers(ctx context.Context, pgDB *sql.DB) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM users
+		WHERE subscribed=true;
+	`
+
+	var count int
+
+	err := pgDB.QueryRowContext(ctx, query).Scan(&count)
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Error counting subscribed users", "error", err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func GetSubscribedUsers(ctx context.Context, pgDB *sql.DB) ([]int64, error) {
+	query := `
+		SELECT chat_id
+		FROM users
+		WHERE subscribed=true;
+	`
+
+	rows, err := pgDB.QueryContext(ctx, query)
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Error loading subscribed users", "error", err)
 		return nil, err
 	}
 
@@ -36,7 +118,7 @@ func GetSubscribedUsersForHour(ctx context.Context, pgDB *sql.DB, nowUTC time.Ti
 		var chatId int64
 
 		if err := rows.Scan(&chatId); err != nil {
-			log.Println(err)
+			slog.ErrorContext(ctx, "Error loading subscribed users", "error", err)
 			return nil, err
 		}
 
@@ -44,13 +126,58 @@ func GetSubscribedUsersForHour(ctx context.Context, pgDB *sql.DB, nowUTC time.Ti
 	}
 
 	if err := rows.Err(); err != nil {
-		log.Println(err)
+		slog.ErrorContext(ctx, "Error loading subscribed users", "error", err)
 		return nil, err
 	}
 
 	return chatIDs, nil
 }
 
+type TimezoneCount struct {
+	Timezone    string `json:"timezone"`
+	Subscribers int    `json:"subscribers"`
+}
+
+// GetSubscriberCountsByTimezone counts subscribed users per stored timezone, busiest first. Unset timezones are grouped as "UTC (unset)".
+func GetSubscriberCountsByTimezone(ctx context.Context, pgDB *sql.DB) ([]TimezoneCount, error) {
+	query := `
+		SELECT COALESCE(timezone, 'UTC (unset)') AS tz, COUNT(*)
+		FROM users
+		WHERE subscribed=true
+		GROUP BY tz
+		ORDER BY COUNT(*) DESC, tz;
+	`
+
+	rows, err := pgDB.QueryContext(ctx, query)
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Error loading subscriber counts", "error", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var counts []TimezoneCount
+
+	for rows.Next() {
+		var count TimezoneCount
+
+		if err := rows.Scan(&count.Timezone, &count.Subscribers); err != nil {
+			slog.ErrorContext(ctx, "Error loading subscriber counts", "error", err)
+			return nil, err
+		}
+
+		counts = append(counts, count)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(ctx, "Error loading subscriber counts", "error", err)
+		return nil, err
+	}
+
+	return counts, nil
+}
+
 func AddNewUser(ctx context.Context, pgDB *sql.DB, user User) error {
 	query := `
 		INSERT INTO users (chat_id, first_name, username, subscribed)
@@ -63,7 +190,7 @@ func AddNewUser(ctx context.Context, pgDB *sql.DB, user User) error {
 	_, err := pgDB.ExecContext(ctx, query, user.ChatId, user.FirstName, user.UserName)
 
 	if err != nil {
-		log.Println("Error adding new user:", err)
+		slog.ErrorContext(ctx, "Error adding new user", "error", err)
 		return err
 	}
 
@@ -71,23 +198,68 @@ func AddNewUser(ctx context.Context, pgDB *sql.DB, user User) error {
 }
 
 func UpdateSubscription(ctx context.Context, pgDB *sql.DB, chatID int64, subscribed bool) error {
+	// Re-subscribing clears any reason left behind by MarkUserInactive
 	query := `
-		UPDATE users SET subscribed = $1 WHERE chat_id = $2
+		UPDATE users
+		SET subscribed = $1,
+			inactive_reason = CASE WHEN $1 THEN NULL ELSE inactive_reason END,
+			inactive_at = CASE WHEN $1 THEN NULL ELSE inactive_at END
+		WHERE chat_id = $2
 	`
 
 	_, err := pgDB.ExecContext(ctx, query, subscribed, chatID)
 
 	if err != nil {
-		log.Println("Error updating user subscription:", err)
+		slog.ErrorContext(ctx, "Error updating user subscription", "error", err)
 		return err
 	}
 
 	if subscribed {
-		log.Println("User subscribed:", chatID)
+		slog.InfoContext(ctx, "User subscribed", "chat_id", chatID)
 	} else {
-		log.Println("User un-subscribed:", chatID)
+		slog.InfoContext(ctx, "User unsubscribed", "chat_id", chatID)
+	}
+
+	return nil
+}
+
+// MarkUserInactive unsubscribes a user the bot can no longer reach, recording why and when.
+func MarkUserInactive(ctx context.Context, pgDB *sql.DB, chatID int64, reason string) error {
+	query := `
+		UPDATE users
+		SET subscribed = false,
+			inactive_reason = $1,
+			inactive_at = NOW()
+		WHERE chat_id = $2
+	`
+
+	_, err := pgDB.ExecContext(ctx, query, reason, chatID)
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Error marking user inactive", "error", err)
+		return err
+	}
+
+	slog.InfoContext(ctx, "User marked inactive", "chat_id", chatID, "reason", reason)
+
+	return nil
+}
+
+// UpdateUserCategory sets the user's preferred quote category. An empty category clears it (any category).
+func UpdateUserCategory(ctx context.Context, pgDB *sql.DB, chatID int64, category string) error {
+	query := `
+		UPDATE users SET category = NULLIF($1, '') WHERE chat_id = $2
+	`
+
+	_, err := pgDB.ExecContext(ctx, query, category, chatID)
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Error updating user category", "error", err)
+		return err
 	}
 
+	slog.InfoContext(ctx, "User category updated", "chat_id", chatID, "category", category)
+
 	return nil
 }
 
@@ -99,11 +271,50 @@ func UpdateUserTimezone(ctx context.Context, pgDB *sql.DB, chatID int64, tz stri
 	_, err := pgDB.ExecContext(ctx, query, tz, chatID)
 
 	if err != nil {
-		log.Println("Error updating user timezone:", err)
+		slog.ErrorContext(ctx, "Error updating user timezone", "error", err)
+		return err
+	}
+
+	slog.InfoContext(ctx, "User timezone updated", "chat_id", chatID, "timezone", tz)
+
+	return nil
+}
+
+// A null send_hour means the user follows the global `send_hour` from bot_config.
+func GetUserSendHour(ctx context.Context, pgDB *sql.DB, chatID int64) (sql.NullInt64, error) {
+	query := `
+		SELECT send_hour FROM users WHERE chat_id = $1
+	`
+
+	var sendHour sql.NullInt64
+
+	err := pgDB.QueryRowContext(ctx, query, chatID).Scan(&sendHour)
+
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		slog.ErrorContext(ctx, "Error getting user send hour", "error", err)
+		return sql.NullInt64{}, err
+	}
+
+	return sendHour, nil
+}
+
+func UpdateUserSendHour(ctx context.Context, pgDB *sql.DB, chatID int64, sendHour sql.NullInt64) error {
+	query := `
+		UPDATE users SET send_hour = $1 WHERE chat_id = $2
+	`
+
+	_, err := pgDB.ExecContext(ctx, query, sendHour, chatID)
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Error updating user send hour", "error", err)
 		return err
 	}
 
-	log.Println("User", chatID, "timezone updated to ", tz)
+	if sendHour.Valid {
+		slog.InfoContext(ctx, "User send hour updated", "chat_id", chatID, "send_hour", sendHour.Int64)
+	} else {
+		slog.InfoContext(ctx, "User send hour reset to default", "chat_id", chatID)
+	}
 
 	return nil
 }
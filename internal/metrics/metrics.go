@@ -0,0 +1,135 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "scheduler"
+
+// Job label used for work that wasn't started by a scheduled job, e.g. an admin /broadcast
+const adHocJob = "adhoc"
+
+var registry = prometheus.NewRegistry()
+
+var (
+	JobRuns = register(prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "job_runs_total",
+		Help:      "Scheduled job runs by job and status (ok, failed).",
+	}, []string{"job", "status"}))
+
+	JobDuration = register(prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "job_duration_seconds",
+		Help:      "How long each scheduled job run took.",
+		Buckets:   []float64{0.1, 0.5, 1, 5, 15, 30, 60, 120, 300, 600},
+	}, []string{"job"}))
+
+	BroadcastRuns = register(prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "broadcast_runs_total",
+		Help:      "Broadcast runs by job and result (ok, failed).",
+	}, []string{"job", "result"}))
+
+	BroadcastSends = register(prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "broadcast_sends_total",
+		Help:      "Per-user broadcast sends by job and outcome (sent, failed, permanent, skipped).",
+	}, []string{"job", "outcome"}))
+
+	SendDuration = register(prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "broadcast_send_duration_seconds",
+		Help:      "Time to deliver one broadcast message, retries included.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"job"}))
+
+	QuoteFetches = register(prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "quote_fetches_total",
+		Help:      "Quote fetches by job, provider and outcome (ok, error).",
+	}, []string{"job", "provider", "outcome"}))
+
+	QuoteFetchDuration = register(prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "quote_fetch_duration_seconds",
+		Help:      "Time to fetch a quote from the quote provider.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"job", "provider"}))
+
+	QuoteBreakerState = register(prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "quote_breaker_state",
+		Help:      "Quote provider circuit breaker state: 0 closed, 1 open, 2 half-open.",
+	}, []string{"provider"}))
+
+	UpdatesProcessed = register(prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "telegram_updates_processed_total",
+		Help:      "Telegram updates handled, through polling or the webhook.",
+	}))
+
+	PollErrors = register(prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "telegram_poll_errors_total",
+		Help:      "getUpdates calls that failed.",
+	}))
+
+	TelegramOffset = register(prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "telegram_offset",
+		Help:      "The current Telegram update offset.",
+	}))
+)
+
+func init() {
+	registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+}
+
+func register[C prometheus.Collector](collector C) C {
+	registry.MustRegister(collector)
+
+	return collector
+}
+
+// RegisterSubscribedUsers exposes the subscribed user count, read through count on every scrape.
+func RegisterSubscribedUsers(count func() (int, error)) {
+	registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "subscribed_users",
+		Help:      "Users currently subscribed to the daily quote.",
+	}, func() float64 {
+		subscribed, err := count()
+
+		if err != nil {
+			return -1
+		}
+
+		return float64(subscribed)
+	}))
+}
+
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry})
+}
+
+type jobKey struct{}
+
+// WithJob tags ctx with the name of the scheduled job doing the work, so metrics recorded under it carry the job label.
+func WithJob(ctx context.Context, job string) context.Context {
+	return context.WithValue(ctx, jobKey{}, job)
+}
+
+// Job returns the job name set by WithJob, or "adhoc" for work outside a scheduled job.
+func Job(ctx context.Context) string {
+	if job, ok := ctx.Value(jobKey{}).(string); ok && job != "" {
+		return job
+	}
+
+	return adHocJob
+}
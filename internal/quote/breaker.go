@@ -0,0 +1,173 @@
+package quote
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling the wrapped provider while the breaker is open.
+var ErrCircuitOpen = errors.New("quote provider circuit open")
+
+type BreakerState int
+
+const (
+	BreakerClosed BreakerState = iota
+	BreakerOpen
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// Breaker is a QuoteProvider that stops calling a failing provider. After Threshold consecutive failures it opens
+// and fails fast with ErrCircuitOpen, so callers move straight on to cached or local quotes. Once Cooldown has passed
+// it lets a single probe through (half-open): success closes it again, failure reopens it for another Cooldown.
+type Breaker struct {
+	Provider  QuoteProvider
+	Threshold int
+	Cooldown  time.Duration
+
+	// Called on every state change, e.g. to update a metric. Runs with the breaker's lock held, so keep it quick.
+	OnStateChange func(from BreakerState, to BreakerState)
+
+	mutex    sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func NewBreaker(provider QuoteProvider, threshold int, cooldown time.Duration) *Breaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+
+	return &Breaker{
+		Provider:  provider,
+		Threshold: threshold,
+		Cooldown:  cooldown,
+	}
+}
+
+func (b *Breaker) Name() string {
+	return b.Provider.Name()
+}
+
+func (b *Breaker) State() BreakerState {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.state
+}
+
+func (b *Breaker) FetchQuote(ctx context.Context, category string) (Quote, error) {
+	if !b.allow() {
+		return Quote{}, ErrCircuitOpen
+	}
+
+	fetchedQuote, err := b.Provider.FetchQuote(ctx, category)
+
+	// The caller giving up says nothing about the provider's health
+	if err != nil && ctx.Err() != nil {
+		b.release()
+		return fetchedQuote, err
+	}
+
+	b.record(err)
+
+	return fetchedQuote, err
+}
+
+// allow reports whether a call may go through, moving an open breaker to half-open once its cooldown is over.
+// Only one probe is let through while half-open.
+func (b *Breaker) allow() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.Cooldown {
+			return false
+		}
+
+		b.setState(BreakerHalfOpen)
+		b.probing = true
+
+		return true
+	case BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+
+		b.probing = true
+
+		return true
+	default:
+		return true
+	}
+}
+
+// release gives up a half-open probe slot without recording an outcome.
+func (b *Breaker) release() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.probing = false
+}
+
+func (b *Breaker) record(err error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.probing = false
+
+	if err == nil {
+		b.failures = 0
+
+		if b.state != BreakerClosed {
+			b.setState(BreakerClosed)
+		}
+
+		return
+	}
+
+	b.failures++
+
+	if b.state == BreakerHalfOpen || b.failures >= b.Threshold {
+		b.openedAt = time.Now()
+
+		if b.state != BreakerOpen {
+			b.setState(BreakerOpen)
+		}
+	}
+}
+
+// Must be called with the mutex held
+func (b *Breaker) setState(to BreakerState) {
+	from := b.state
+	b.state = to
+
+	switch to {
+	case BreakerOpen:
+		slog.Warn("Quote provider circuit open", "provider", b.Provider.Name(), "failures", b.failures, "cooldown", b.Cooldown.String())
+	case BreakerHalfOpen:
+		slog.Info("Quote provider circuit half-open, sending a probe", "provider", b.Provider.Name())
+	case BreakerClosed:
+		slog.Info("Quote provider circuit closed, provider recovered", "provider", b.Provider.Name())
+	}
+
+	if b.OnStateChange != nil {
+		b.OnStateChange(from, to)
+	}
+}
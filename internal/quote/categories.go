@@ -0,0 +1,16 @@
+package quote
+
+import "strings"
+
+// Categories users can pick with /category. Each is passed as-is to the quote API.
+var Categories = []string{"life", "wisdom", "motivation", "happiness", "mindfulness", "love"}
+
+func IsValidCategory(category string) bool {
+	for _, c := range Categories {
+		if strings.EqualFold(category, c) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,61 @@
+package quote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// Chain is a QuoteProvider that tries each of its providers in order and returns the first quote found.
+type Chain struct {
+	Providers []QuoteProvider
+}
+
+func NewChain(providers ...QuoteProvider) *Chain {
+	return &Chain{
+		Providers: providers,
+	}
+}
+
+func (c *Chain) Name() string {
+	names := make([]string, 0, len(c.Providers))
+
+	for _, provider := range c.Providers {
+		names = append(names, provider.Name())
+	}
+
+	return strings.Join(names, ",")
+}
+
+func (c *Chain) FetchQuote(ctx context.Context, category string) (Quote, error) {
+	var errs []error
+
+	for _, provider := range c.Providers {
+		fetchedQuote, err := provider.FetchQuote(ctx, category)
+
+		if err == nil && fetchedQuote.Text != "" {
+			return fetchedQuote, nil
+		}
+
+		if err == nil {
+			err = ErrNoQuote
+		}
+
+		slog.WarnContext(ctx, "Quote provider failed, trying the next one", "provider", provider.Name(), "error", err)
+
+		errs = append(errs, fmt.Errorf("%s: %w", provider.Name(), err))
+
+		// No point asking the rest once the caller has given up
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	if len(errs) == 0 {
+		return Quote{}, ErrNoQuote
+	}
+
+	return Quote{}, errors.Join(errs...)
+}
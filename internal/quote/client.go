@@ -5,16 +5,19 @@ import (
 	"time"
 )
 
+// Client is the QuoteProvider backed by the HTTP quote API at QUOTE_API_URL.
 type Client struct {
 	Client       *http.Client
 	QuoteBaseURL string
-	DefaultQuote string
 }
 
-func NewClient(quotesBaseURL string, defaultQuote string) *Client {
+func NewClient(quotesBaseURL string, timeout time.Duration) *Client {
 	return &Client{
-		Client:       &http.Client{Timeout: 5 * time.Second},
+		Client:       &http.Client{Timeout: timeout},
 		QuoteBaseURL: quotesBaseURL,
-		DefaultQuote: defaultQuote,
 	}
 }
+
+func (c *Client) Name() string {
+	return "api"
+}
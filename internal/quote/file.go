@@ -0,0 +1,191 @@
+package quote
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileProvider is a QuoteProvider that serves quotes from a local JSON or CSV file, loaded once at startup.
+type FileProvider struct {
+	Path   string
+	quotes []Quote
+}
+
+func NewFileProvider(path string) (*FileProvider, error) {
+	quotes, err := LoadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(quotes) == 0 {
+		return nil, fmt.Errorf("quote file %s has no quotes", path)
+	}
+
+	return &FileProvider{
+		Path:   path,
+		quotes: quotes,
+	}, nil
+}
+
+func (f *FileProvider) Name() string {
+	return "file"
+}
+
+func (f *FileProvider) FetchQuote(ctx context.Context, category string) (Quote, error) {
+	var matching []Quote
+
+	for _, q := range f.quotes {
+		if category == "" || strings.EqualFold(q.Category, category) {
+			matching = append(matching, q)
+		}
+	}
+
+	if len(matching) == 0 {
+		return Quote{}, ErrNoQuote
+	}
+
+	return matching[rand.IntN(len(matching))], nil
+}
+
+// LoadFile reads quotes from a .json file (an array of {"id", "text", "author", "category"} objects) or a .csv file
+// with a header row naming the text, author and optional category and id columns. Entries without text are skipped.
+// IDs are prefixed with "file:" and derived from the text and author when missing, so they stay stable across loads.
+func LoadFile(path string) ([]Quote, error) {
+	file, err := os.Open(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	var quotes []Quote
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		quotes, err = decodeJSON(file)
+	case ".csv":
+		quotes, err = decodeCSV(file)
+	default:
+		return nil, fmt.Errorf("unsupported quote file %s: expected .json or .csv", path)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("reading quote file %s: %w", path, err)
+	}
+
+	loaded := make([]Quote, 0, len(quotes))
+
+	for _, q := range quotes {
+		q.Text = strings.TrimSpace(q.Text)
+		q.Author = strings.TrimSpace(q.Author)
+		q.Category = strings.ToLower(strings.TrimSpace(q.Category))
+
+		if q.Text == "" {
+			continue
+		}
+
+		if q.ID == "" {
+			q.ID = contentID(q.Text, q.Author)
+		}
+
+		q.ID = "file:" + q.ID
+
+		loaded = append(loaded, q)
+	}
+
+	return loaded, nil
+}
+
+func decodeJSON(r io.Reader) ([]Quote, error) {
+	var raw []struct {
+		ID       string `json:"id"`
+		Text     string `json:"text"`
+		Author   string `json:"author"`
+		Category string `json:"category"`
+	}
+
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	quotes := make([]Quote, 0, len(raw))
+
+	for _, entry := range raw {
+		quotes = append(quotes, Quote{ID: entry.ID, Text: entry.Text, Author: entry.Author, Category: entry.Category})
+	}
+
+	return quotes, nil
+}
+
+func decodeCSV(r io.Reader) ([]Quote, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int, len(header))
+
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	if _, ok := columns["text"]; !ok {
+		return nil, errors.New("CSV header has no text column")
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+
+		if !ok || i >= len(record) {
+			return ""
+		}
+
+		return record[i]
+	}
+
+	var quotes []Quote
+
+	for {
+		record, readErr := reader.Read()
+
+		if errors.Is(readErr, io.EOF) {
+			break
+		}
+
+		if readErr != nil {
+			return nil, readErr
+		}
+
+		quotes = append(quotes, Quote{
+			ID:       field(record, "id"),
+			Text:     field(record, "text"),
+			Author:   field(record, "author"),
+			Category: field(record, "category"),
+		})
+	}
+
+	return quotes, nil
+}
+
+// contentID derives a stable ID from a quote's text and author.
+func contentID(text string, author string) string {
+	sum := sha256.Sum256([]byte(text + "\x00" + author))
+
+	return hex.EncodeToString(sum[:8])
+}
@@ -6,16 +6,32 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 )
 
-func (c *Client) GetQuote(ctx context.Context) (string, error) {
+// FetchQuote gets a random quote from the API, keeping its id so it can be cached and deduplicated.
+// A non-empty category is passed to the API as the `category` query parameter. The API may not filter on it, so the
+// returned quote only carries a category if the response names one.
+func (c *Client) FetchQuote(ctx context.Context, category string) (Quote, error) {
 	var requestBody io.Reader
 
-	httpRequest, requestErr := http.NewRequestWithContext(ctx, http.MethodGet, c.QuoteBaseURL, requestBody)
+	quoteURL, urlErr := url.Parse(c.QuoteBaseURL)
+
+	if urlErr != nil {
+		return Quote{}, urlErr
+	}
+
+	if category != "" {
+		query := quoteURL.Query()
+		query.Set("category", category)
+		quoteURL.RawQuery = query.Encode()
+	}
+
+	httpRequest, requestErr := http.NewRequestWithContext(ctx, http.MethodGet, quoteURL.String(), requestBody)
 
 	if requestErr != nil {
-		return "", requestErr
+		return Quote{}, requestErr
 	}
 
 	httpRequest.Header.Set("Content-Type", "application/json")
@@ -23,20 +39,21 @@ func (c *Client) GetQuote(ctx context.Context) (string, error) {
 	response, responseErr := c.Client.Do(httpRequest)
 
 	if responseErr != nil {
-		return "", responseErr
+		return Quote{}, responseErr
 	}
 
 	defer response.Body.Close()
 
 	if response.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(response.Body)
-		return "", fmt.Errorf("Error getting quotes %d: %s", response.StatusCode, strings.TrimSpace(string(body)))
+		return Quote{}, fmt.Errorf("Error getting quotes %d: %s", response.StatusCode, strings.TrimSpace(string(body)))
 	}
 
 	var raw struct {
 		Id     string `json:"id"`
 		Quote  string `json:"text"`
-		Author string `json:"byName"`
+		Author   string `json:"byName"`
+		Category string `json:"category"`
 	}
 
 	responseDecoder := json.NewDecoder(response.Body)
@@ -44,10 +61,8 @@ func (c *Client) GetQuote(ctx context.Context) (string, error) {
 	decodeErr := responseDecoder.Decode(&raw)
 
 	if decodeErr != nil {
-		return "", decodeErr
+		return Quote{}, decodeErr
 	}
 
-	quoteWithAuthor := raw.Quote + "\n\n" + "- " + raw.Author + "\n"
-
-	return quoteWithAuthor, nil
+	return Quote{ID: raw.Id, Text: raw.Quote, Author: raw.Author, Category: strings.ToLower(strings.TrimSpace(raw.Category))}, nil
 }
@@ -0,0 +1,43 @@
+package quote
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+
+	"github.com/sriram651/go-scheduler/internal/db"
+)
+
+// PostgresProvider is a QuoteProvider that serves quotes from the curated_quotes table.
+type PostgresProvider struct {
+	Database *sql.DB
+}
+
+func NewPostgresProvider(database *sql.DB) *PostgresProvider {
+	return &PostgresProvider{
+		Database: database,
+	}
+}
+
+func (p *PostgresProvider) Name() string {
+	return "postgres"
+}
+
+func (p *PostgresProvider) FetchQuote(ctx context.Context, category string) (Quote, error) {
+	curated, ok, err := db.GetRandomCuratedQuote(ctx, p.Database, category)
+
+	if err != nil {
+		return Quote{}, err
+	}
+
+	if !ok {
+		return Quote{}, ErrNoQuote
+	}
+
+	return Quote{
+		ID:       "curated:" + strconv.FormatInt(curated.ID, 10),
+		Text:     curated.Text,
+		Author:   curated.Author,
+		Category: curated.Category,
+	}, nil
+}
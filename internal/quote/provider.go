@@ -0,0 +1,19 @@
+package quote
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNoQuote is returned by providers that have nothing to offer for the requested category.
+var ErrNoQuote = errors.New("no quote available")
+
+// QuoteProvider is a source of quotes. Implementations must return IDs that are stable for the same quote
+// and don't collide with other providers' IDs, since broadcasts cache and deduplicate quotes by ID.
+type QuoteProvider interface {
+	// Name identifies the provider in logs and config, e.g. "api" or "file"
+	Name() string
+
+	// FetchQuote returns a quote in category, or any quote when category is empty
+	FetchQuote(ctx context.Context, category string) (Quote, error)
+}
@@ -0,0 +1,15 @@
+package quote
+
+// Quote is a single quote as returned by the quote API. ID is the API's own identifier.
+// Category is the one the provider says the quote belongs to, empty if it didn't say.
+type Quote struct {
+	ID       string
+	Text     string
+	Author   string
+	Category string
+}
+
+// Format renders the quote the way it is sent to users: "quote text\n\n- Author\n"
+func (q Quote) Format() string {
+	return q.Text + "\n\n" + "- " + q.Author + "\n"
+}
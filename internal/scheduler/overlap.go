@@ -0,0 +1,75 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// OverlapPolicy decides what happens when a job's tick fires while its previous run is still going.
+type OverlapPolicy string
+
+const (
+	// Drop the new tick
+	OverlapSkip OverlapPolicy = "skip"
+	// Start the new tick as soon as the previous run finishes
+	OverlapDelay OverlapPolicy = "delay"
+	// Run both at once
+	OverlapAllow OverlapPolicy = "allow"
+)
+
+func ParseOverlapPolicy(raw string) (OverlapPolicy, error) {
+	switch policy := OverlapPolicy(raw); policy {
+	case OverlapSkip, OverlapDelay, OverlapAllow:
+		return policy, nil
+	case "":
+		return OverlapSkip, nil
+	}
+
+	return "", fmt.Errorf("unknown overlap policy %q (want skip, delay or allow)", raw)
+}
+
+// overlapWrapper returns the wrapper implementing policy around a job's ticks, logging skipped and delayed ticks under jobName.
+// It sees each tick's firedAt already stamped, so a delayed tick still runs for the time it was scheduled.
+func overlapWrapper(jobName string, policy OverlapPolicy) func(tickFunc) tickFunc {
+	switch policy {
+	case OverlapSkip:
+		return func(run tickFunc) tickFunc {
+			var running atomic.Bool
+
+			return func(ctx context.Context, firedAt time.Time) {
+				if !running.CompareAndSwap(false, true) {
+					slog.WarnContext(ctx, "Previous run still in progress, skipping this tick", "job", jobName, "fired_at", firedAt.Format(time.RFC3339))
+					return
+				}
+
+				defer running.Store(false)
+
+				run(ctx, firedAt)
+			}
+		}
+
+	case OverlapDelay:
+		return func(run tickFunc) tickFunc {
+			var runMutex sync.Mutex
+
+			return func(ctx context.Context, firedAt time.Time) {
+				queuedAt := time.Now()
+
+				runMutex.Lock()
+				defer runMutex.Unlock()
+
+				if waited := time.Since(queuedAt); waited > time.Second {
+					slog.WarnContext(ctx, "Tick delayed waiting for the previous run", "job", jobName, "fired_at", firedAt.Format(time.RFC3339), "waited", waited.Round(time.Second).String())
+				}
+
+				run(ctx, firedAt)
+			}
+		}
+	}
+
+	return func(run tickFunc) tickFunc { return run }
+}
@@ -2,36 +2,217 @@ package scheduler
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
+	"runtime/debug"
+	"slices"
+	"sync/atomic"
 	"time"
 
 	"github.com/robfig/cron/v3"
+	"github.com/sriram651/go-scheduler/internal/db"
+	"github.com/sriram651/go-scheduler/internal/logging"
+	"github.com/sriram651/go-scheduler/internal/metrics"
 )
 
-type Scheduler struct {
+// Handler is the work a job does on each tick. firedAt is the tick time in UTC.
+type Handler func(ctx context.Context, firedAt time.Time) error
+
+type Job struct {
+	Name     string
 	Schedule string
+	Enabled  bool
+	Overlap  OverlapPolicy
+	Handler  Handler
+
+	// runJob behind the overlap policy, set by Register
+	run tickFunc
+}
+
+// tickFunc runs one tick of a job that fired at firedAt.
+type tickFunc func(ctx context.Context, firedAt time.Time)
+
+type Scheduler struct {
+	jobs     []Job
+	Database *sql.DB
+	running  atomic.Bool
 }
 
-func New(schedule string) *Scheduler {
+func New(database *sql.DB) *Scheduler {
 	return &Scheduler{
-		Schedule: schedule,
+		Database: database,
+	}
+}
+
+// Register adds a job to the registry. It fails on a duplicate name or a schedule robfig/cron can't parse.
+func (s *Scheduler) Register(job Job) error {
+	for _, existing := range s.jobs {
+		if existing.Name == job.Name {
+			return fmt.Errorf("job %q is already registered", job.Name)
+		}
+	}
+
+	if _, err := cron.ParseStandard(job.Schedule); err != nil {
+		return fmt.Errorf("job %q has an invalid schedule %q: %w", job.Name, job.Schedule, err)
 	}
+
+	overlap, overlapErr := ParseOverlapPolicy(string(job.Overlap))
+
+	if overlapErr != nil {
+		return fmt.Errorf("job %q: %w", job.Name, overlapErr)
+	}
+
+	job.Overlap = overlap
+
+	job.run = overlapWrapper(job.Name, job.Overlap)(func(ctx context.Context, firedAt time.Time) {
+		s.runJob(ctx, job, firedAt)
+	})
+
+	s.jobs = append(s.jobs, job)
+
+	return nil
 }
 
-func (s *Scheduler) Start(ctx context.Context, job func()) {
+func (s *Scheduler) Start(ctx context.Context) {
 	// Start the jobs here...
 	c := cron.New(cron.WithLocation(time.Local))
 
-	c.AddFunc(s.Schedule, job)
+	for _, job := range s.jobs {
+		if !job.Enabled {
+			slog.Info("Job disabled, not scheduling", "job", job.Name)
+			continue
+		}
+
+		if _, err := c.AddJob(job.Schedule, stampTick(ctx, job.run)); err != nil {
+			slog.Error("Could not schedule job", "job", job.Name, "error", err)
+			continue
+		}
 
-	log.Println("✅ Starting cron service...")
+		slog.Info("Job scheduled", "job", job.Name, "schedule", job.Schedule, "overlap", string(job.Overlap))
+	}
+
+	slog.Info("Starting cron service")
 
 	c.Start()
+	s.running.Store(true)
 
 	<-ctx.Done()
 
+	s.running.Store(false)
 	<-c.Stop().Done()
 
-	fmt.Println("Cron reminder service shutting down.")
+	slog.Info("Cron service shutting down")
+}
+
+// CatchUp runs the ticks of the named job missed since its last finished run, going back at most window from now,
+// oldest first. Each one goes through the job's overlap policy and is recorded in job_runs, like a live tick.
+// Disabled jobs and jobs that never finished a run, e.g. on a fresh database or after a rename, have nothing to catch up.
+func (s *Scheduler) CatchUp(ctx context.Context, name string, window time.Duration, now time.Time) {
+	index := slices.IndexFunc(s.jobs, func(job Job) bool { return job.Name == name })
+
+	if index < 0 || !s.jobs[index].Enabled || window <= 0 {
+		return
+	}
+
+	job := s.jobs[index]
+
+	lastRun, ok, err := db.GetLastJobRunTime(ctx, s.Database, job.Name)
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Catch-up skipped, could not read the last job run", "job", job.Name, "error", err)
+		return
+	}
+
+	if !ok {
+		return
+	}
+
+	// Register has already parsed it
+	schedule, _ := cron.ParseStandard(job.Schedule)
+
+	after := lastRun
+
+	if windowStart := now.Add(-window); after.Before(windowStart) {
+		after = windowStart
+	}
+
+	for _, tick := range missedTicks(schedule, after, now) {
+		if ctx.Err() != nil {
+			return
+		}
+
+		slog.InfoContext(ctx, "Catching up missed tick", "job", job.Name, "fired_at", tick.Format(time.RFC3339))
+
+		job.run(ctx, tick)
+	}
+}
+
+// missedTicks lists the ticks of schedule after after and up to until, in UTC.
+func missedTicks(schedule cron.Schedule, after time.Time, until time.Time) []time.Time {
+	var ticks []time.Time
+
+	for tick := schedule.Next(after); !tick.IsZero() && !tick.After(until); tick = schedule.Next(tick) {
+		ticks = append(ticks, tick.UTC())
+	}
+
+	return ticks
+}
+
+// Running reports whether the cron is started. Safe to call from any goroutine.
+func (s *Scheduler) Running() bool {
+	return s.running.Load()
+}
+
+// stampTick turns run into a cron job that records the tick time as soon as cron fires it, before the overlap
+// policy can hold it back. A tick delayed behind a long run still reaches the handler as e.g. 09:00, not 10:02.
+func stampTick(ctx context.Context, run tickFunc) cron.Job {
+	return cron.FuncJob(func() {
+		// Cron fires on whole seconds, a moment after the tick
+		run(ctx, time.Now().UTC().Truncate(time.Second))
+	})
+}
+
+// runJob runs a single tick of job, fired at firedAt, and records it in job_runs.
+func (s *Scheduler) runJob(ctx context.Context, job Job, firedAt time.Time) {
+	startedAt := time.Now()
+
+	// Metrics and logs recorded further down (e.g. by the broadcast) are labelled with this job
+	ctx = metrics.WithJob(ctx, job.Name)
+	ctx = logging.With(ctx, "job", job.Name)
+
+	slog.InfoContext(ctx, "Job started")
+
+	// Run history is best-effort: a failed insert leaves runID at 0 and the job still runs
+	runID, _ := db.StartJobRun(ctx, s.Database, job.Name, firedAt)
+
+	runErr := callHandler(ctx, job, firedAt)
+
+	duration := time.Since(startedAt)
+
+	if runErr != nil {
+		slog.ErrorContext(ctx, "Job failed", "duration", duration.Round(time.Millisecond).String(), "error", runErr)
+		metrics.JobRuns.WithLabelValues(job.Name, db.JobRunFailed).Inc()
+	} else {
+		slog.InfoContext(ctx, "Job finished", "duration", duration.Round(time.Millisecond).String())
+		metrics.JobRuns.WithLabelValues(job.Name, db.JobRunOK).Inc()
+	}
+
+	metrics.JobDuration.WithLabelValues(job.Name).Observe(duration.Seconds())
+
+	if runID != 0 {
+		db.FinishJobRun(context.WithoutCancel(ctx), s.Database, runID, runErr, duration)
+	}
+}
+
+// callHandler runs the job's handler, turning a panic into an error so one bad tick can't take the process down.
+func callHandler(ctx context.Context, job Job, firedAt time.Time) (runErr error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			slog.ErrorContext(ctx, "Job panicked", "panic", fmt.Sprint(recovered), "stack", string(debug.Stack()))
+			runErr = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+
+	return job.Handler(ctx, firedAt)
 }
@@ -0,0 +1,125 @@
+package scheduler
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// A tick queued behind a long run must still reach the handler with its own time, or a broadcast
+// delayed from 09:00 to 10:02 would send to the 10:00 cohort and skip the 09:00 one.
+func TestDelayedTickKeepsItsTime(t *testing.T) {
+	var firedAts []time.Time
+	var firedMutex sync.Mutex
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 2)
+
+	run := overlapWrapper("test", OverlapDelay)(func(ctx context.Context, firedAt time.Time) {
+		firedMutex.Lock()
+		firedAts = append(firedAts, firedAt)
+		firedMutex.Unlock()
+
+		started <- struct{}{}
+		<-release
+	})
+
+	tick := stampTick(context.Background(), run)
+
+	var ticksWaitGroup sync.WaitGroup
+
+	ticksWaitGroup.Go(tick.Run)
+	<-started
+
+	// Fired while the first run is still going, so the delay policy queues it
+	secondFiredAt := time.Now().UTC()
+	ticksWaitGroup.Go(tick.Run)
+
+	time.Sleep(1500 * time.Millisecond)
+
+	releasedAt := time.Now().UTC()
+	close(release)
+
+	ticksWaitGroup.Wait()
+
+	if len(firedAts) != 2 {
+		t.Fatalf("handler ran %d times, want 2", len(firedAts))
+	}
+
+	got := firedAts[1]
+
+	// Stamped a moment after secondFiredAt and truncated to the second
+	if got.Before(secondFiredAt.Add(-time.Second)) || got.After(secondFiredAt.Add(100*time.Millisecond)) {
+		t.Errorf("delayed tick firedAt = %s, want the time it fired (%s), not when it got to run (%s)",
+			got.Format(time.RFC3339Nano), secondFiredAt.Format(time.RFC3339Nano), releasedAt.Format(time.RFC3339Nano))
+	}
+
+	if got.Location() != time.UTC {
+		t.Errorf("firedAt location = %s, want UTC", got.Location())
+	}
+}
+
+func TestMissedTicks(t *testing.T) {
+	at := func(value string) time.Time {
+		parsed, err := time.Parse(time.RFC3339, value)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return parsed
+	}
+
+	tests := []struct {
+		name     string
+		schedule string
+		after    string
+		until    string
+		want     []string
+	}{
+		{
+			name:     "hourly, including the current hour's tick",
+			schedule: "CRON_TZ=UTC 0 * * * *",
+			after:    "2026-03-14T09:00:00Z",
+			until:    "2026-03-14T12:30:00Z",
+			want:     []string{"2026-03-14T10:00:00Z", "2026-03-14T11:00:00Z", "2026-03-14T12:00:00Z"},
+		},
+		{
+			name:     "daily, nothing due yet",
+			schedule: "CRON_TZ=UTC 0 9 * * *",
+			after:    "2026-03-14T09:00:00Z",
+			until:    "2026-03-15T08:59:00Z",
+		},
+		{
+			name:     "non-hourly schedule in its own zone",
+			schedule: "CRON_TZ=Asia/Kolkata 0 9,21 * * *",
+			after:    "2026-03-13T16:00:00Z",
+			until:    "2026-03-14T16:00:00Z",
+			want:     []string{"2026-03-14T03:30:00Z", "2026-03-14T15:30:00Z"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := cron.ParseStandard(tt.schedule)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+
+			for _, tick := range missedTicks(schedule, at(tt.after), at(tt.until)) {
+				got = append(got, tick.Format(time.RFC3339))
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("missedTicks = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package telegram
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/sriram651/go-scheduler/internal/db"
+)
+
+// AdminActions is what the admin commands need from outside the telegram package. Wired up by internal/app.
+type AdminActions struct {
+	// Pushes a new global send hour to every component holding a copy
+	SetSendHour func(sendHour int)
+	// Sends text to every subscribed user right away, returning the delivery counts
+	Broadcast func(ctx context.Context, text string) (sent int, failed int, err error)
+	// Fetches a quote the same way a scheduled broadcast would, without sending it
+	PreviewQuote func(ctx context.Context) string
+}
+
+func (c *Client) SetAdmins(chatIDs []int64, actions AdminActions) {
+	c.admins = make(map[int64]bool, len(chatIDs))
+
+	for _, chatID := range chatIDs {
+		c.admins[chatID] = true
+	}
+
+	c.adminActions = actions
+}
+
+func (c *Client) isAdmin(chatID int64) bool {
+	return c.admins[chatID]
+}
+
+// handleAdminCommand runs m if it is an admin command sent from an allowlisted chat, and reports whether it did.
+// Non-admins get no reply, so the commands stay invisible to them.
+func (c *Client) handleAdminCommand(ctx context.Context, m *Message) bool {
+	command, args, _ := strings.Cut(strings.TrimSpace(m.Text), " ")
+	args = strings.TrimSpace(args)
+
+	switch command {
+	case "/stats", "/sethour", "/broadcast", "/testquote":
+	default:
+		return false
+	}
+
+	if !c.isAdmin(m.Chat.ID) {
+		slog.WarnContext(ctx, "Ignoring admin command from non-admin chat", "command", command)
+		return true
+	}
+
+	slog.InfoContext(ctx, "Admin command", "command", command)
+
+	switch command {
+	case "/stats":
+		c.handleStats(ctx, m)
+	case "/sethour":
+		c.handleSetHour(ctx, m, args)
+	case "/broadcast":
+		c.handleAdminBroadcast(ctx, m, args)
+	case "/testquote":
+		c.handleTestQuote(ctx, m)
+	}
+
+	return true
+}
+
+func (c *Client) handleStats(ctx context.Context, m *Message) {
+	counts, err := db.GetSubscriberCountsByTimezone(ctx, c.Database)
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Error loading stats", "error", err)
+		c.replyAdmin(ctx, m.Chat.ID, "Couldn't load stats — check the logs.")
+		return
+	}
+
+	var total int
+
+	var lines strings.Builder
+
+	for _, count := range counts {
+		total += count.Subscribers
+		lines.WriteString("`" + count.Timezone + "` — " + strconv.Itoa(count.Subscribers) + "\n")
+	}
+
+	statsMessage := "📊 *Subscribers:* " + strconv.Itoa(total) + "\n" +
+		"⏰ *Default send hour:* " + formatHour(c.SendHour()) + "\n\n" +
+		lines.String()
+
+	c.replyAdmin(ctx, m.Chat.ID, statsMessage)
+}
+
+func (c *Client) handleSetHour(ctx context.Context, m *Message, args string) {
+	sendHour, convErr := strconv.Atoi(args)
+
+	if convErr != nil || sendHour < 0 || sendHour > 23 {
+		c.replyAdmin(ctx, m.Chat.ID, "Usage: /sethour N — N is an hour from 0 to 23")
+		return
+	}
+
+	if err := db.UpdateBotConfig(ctx, c.Database, "send_hour", sendHour); err != nil {
+		slog.ErrorContext(ctx, "Error updating send_hour", "error", err)
+		c.replyAdmin(ctx, m.Chat.ID, "Couldn't save the send hour — check the logs.")
+		return
+	}
+
+	if c.adminActions.SetSendHour != nil {
+		c.adminActions.SetSendHour(sendHour)
+	} else {
+		c.UpdateSendHour(sendHour)
+	}
+
+	slog.InfoContext(ctx, "send_hour updated by admin", "send_hour", sendHour)
+
+	c.replyAdmin(ctx, m.Chat.ID, "✅ Default send hour is now `"+formatHour(sendHour)+"` local time.")
+}
+
+func (c *Client) handleAdminBroadcast(ctx context.Context, m *Message, text string) {
+	if text == "" {
+		c.replyAdmin(ctx, m.Chat.ID, "Usage: /broadcast <text>")
+		return
+	}
+
+	if c.adminActions.Broadcast == nil {
+		c.replyAdmin(ctx, m.Chat.ID, "Broadcasting isn't available.")
+		return
+	}
+
+	c.replyAdmin(ctx, m.Chat.ID, "📣 Broadcasting to all subscribers…")
+
+	// Sends go out with Markdown parse mode, where a stray _ or * fails every one of them with a 400
+	text = escapeMarkdown(text)
+
+	// Runs off the update loop so other commands keep being answered while it goes out. Tracked so the leader term
+	// (and with it the database) isn't closed under it; see Wait.
+	c.background.Go(func() {
+		sent, failed, err := c.adminActions.Broadcast(ctx, text)
+
+		if err != nil {
+			slog.ErrorContext(ctx, "Error running admin broadcast", "error", err)
+			c.replyAdmin(ctx, m.Chat.ID, "Broadcast failed — check the logs.")
+			return
+		}
+
+		c.replyAdmin(ctx, m.Chat.ID, "📣 Broadcast done — sent: "+strconv.Itoa(sent)+", failed: "+strconv.Itoa(failed))
+	})
+}
+
+// Wait blocks until work the handlers started in the background, e.g. an admin /broadcast, has finished.
+func (c *Client) Wait() {
+	c.background.Wait()
+}
+
+// escapeMarkdown makes text safe to send with the legacy Markdown parse mode, so it arrives exactly as typed.
+func escapeMarkdown(text string) string {
+	return markdownEscaper.Replace(text)
+}
+
+var markdownEscaper = strings.NewReplacer("_", `\_`, "*", `\*`, "`", "\\`", "[", `\[`)
+
+func (c *Client) handleTestQuote(ctx context.Context, m *Message) {
+	if c.adminActions.PreviewQuote == nil {
+		c.replyAdmin(ctx, m.Chat.ID, "Quote preview isn't available.")
+		return
+	}
+
+	c.replyAdmin(ctx, m.Chat.ID, c.adminActions.PreviewQuote(ctx))
+}
+
+func (c *Client) replyAdmin(ctx context.Context, chatId int64, text string) {
+	sendCtx, sendCancel := context.WithTimeout(ctx, c.sendTimeout)
+
+	sendErr := c.HandleSend(sendCtx, chatId, text, nil)
+
+	sendCancel()
+
+	if sendErr != nil {
+		slog.ErrorContext(ctx, "Error sending message", "error", sendErr)
+	}
+}
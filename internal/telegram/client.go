@@ -1,36 +1,104 @@
 package telegram
 
 import (
+	"context"
 	"database/sql"
 	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/sriram651/go-scheduler/internal/metrics"
+	"github.com/sriram651/go-scheduler/internal/tracing"
 )
 
+var tracer = tracing.Tracer("telegram")
+
 type Client struct {
-	baseUrl  string
-	token    string
-	client   *http.Client
-	offset   int
-	sendHour int
-	Database *sql.DB
+	baseUrl     string
+	token       string
+	client      *http.Client
+	pollTimeout time.Duration
+	sendTimeout time.Duration
+	offset      int
+	sendHour    atomic.Int64
+	limiter     *rateLimiter
+	Database    *sql.DB
+
+	admins       map[int64]bool
+	adminActions AdminActions
+
+	// Handler work that outlives its update, e.g. an admin /broadcast
+	background sync.WaitGroup
+
+	// The leader term's context in webhook mode, nil on standby. Webhook requests hold leaderMutex for reading while
+	// they handle an update.
+	leaderMutex sync.RWMutex
+	leaderCtx   context.Context
+
+	// Unix nanoseconds of the last getUpdates call that succeeded, and of the last one that returned at all, 0 if none yet
+	lastPollSuccess atomic.Int64
+	lastPollReturn  atomic.Int64
 }
 
-func NewClient(baseUrl string, token string, httpClientTimeout time.Duration, database *sql.DB) *Client {
+// How many times HandleSend tries a message that keeps getting 429s
+const maxRateLimitAttempts = 3
+
+// Extra time a getUpdates request gets on top of the long poll itself before it is abandoned
+const pollRequestSlack = 5 * time.Second
+
+// NewClient builds a client whose getUpdates long polls wait up to pollTimeout, and whose other API calls
+// (sendMessage, answerCallbackQuery, ...) each get sendTimeout.
+func NewClient(baseUrl string, token string, pollTimeout time.Duration, sendTimeout time.Duration, database *sql.DB) *Client {
 	return &Client{
-		baseUrl:  baseUrl,
-		token:    token,
-		client:   &http.Client{Timeout: httpClientTimeout},
-		offset:   0,
-		Database: database,
+		baseUrl:     baseUrl,
+		token:       token,
+		client:      &http.Client{Timeout: pollTimeout + pollRequestSlack},
+		pollTimeout: pollTimeout,
+		sendTimeout: sendTimeout,
+		offset:      0,
+		limiter:     newRateLimiter(),
+		Database:    database,
 	}
 }
 
+// SendTimeout is the timeout callers outside the package should give each HandleSend.
+func (c *Client) SendTimeout() time.Duration {
+	return c.sendTimeout
+}
+
 func (c *Client) UpdateOffset(newOffset int) {
 	c.offset = newOffset
+
+	metrics.TelegramOffset.Set(float64(newOffset))
 }
 
 func (c *Client) UpdateSendHour(newSendHour int) {
-	c.sendHour = newSendHour
+	c.sendHour.Store(int64(newSendHour))
+}
+
+// SendHour is the global send hour. Safe to call from any goroutine.
+func (c *Client) SendHour() int {
+	return int(c.sendHour.Load())
+}
+
+// LastPollSuccess is when getUpdates last succeeded, zero if it hasn't since startup. Safe to call from any goroutine.
+func (c *Client) LastPollSuccess() time.Time {
+	return unixNanoTime(c.lastPollSuccess.Load())
+}
+
+// LastPollReturn is when a getUpdates call last returned, failed or not, zero if none has since startup.
+// It only stops moving when the polling loop itself is stuck. Safe to call from any goroutine.
+func (c *Client) LastPollReturn() time.Time {
+	return unixNanoTime(c.lastPollReturn.Load())
+}
+
+func unixNanoTime(nanos int64) time.Time {
+	if nanos == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, nanos)
 }
 
 func (c *Client) endpoint(path string, params string) string {
@@ -0,0 +1,84 @@
+package telegram
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// The chat can no longer receive messages from the bot. Match with errors.Is on the error returned by HandleSend.
+var (
+	ErrBotBlocked      = errors.New("bot was blocked by the user")
+	ErrUserDeactivated = errors.New("user is deactivated")
+	ErrChatNotFound    = errors.New("chat not found")
+)
+
+// APIError is a non-200 response from the Telegram Bot API.
+type APIError struct {
+	StatusCode  int
+	Description string
+	RetryAfter  time.Duration
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("upstream error sending message %d: %s", e.StatusCode, e.Description)
+}
+
+// Unwrap maps Telegram's description onto one of the sentinel errors above, if it matches any.
+func (e *APIError) Unwrap() error {
+	description := strings.ToLower(e.Description)
+
+	switch {
+	case e.StatusCode == http.StatusForbidden && strings.Contains(description, "bot was blocked by the user"):
+		return ErrBotBlocked
+	case e.StatusCode == http.StatusForbidden && strings.Contains(description, "bot was kicked"):
+		return ErrBotBlocked
+	case e.StatusCode == http.StatusForbidden && strings.Contains(description, "user is deactivated"):
+		return ErrUserDeactivated
+	case e.StatusCode == http.StatusBadRequest && strings.Contains(description, "chat not found"):
+		return ErrChatNotFound
+	}
+
+	return nil
+}
+
+// Temporary reports whether the same request may succeed later (rate limits and server-side failures).
+func (e *APIError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+// Body Telegram sends back on a non-2xx response
+type apiErrorResponse struct {
+	Ok          bool   `json:"ok"`
+	ErrorCode   int    `json:"error_code"`
+	Description string `json:"description"`
+	Parameters  struct {
+		RetryAfter int `json:"retry_after"`
+	} `json:"parameters"`
+}
+
+func newAPIError(statusCode int, body []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode:  statusCode,
+		Description: strings.TrimSpace(string(body)),
+	}
+
+	var parsed apiErrorResponse
+
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return apiErr
+	}
+
+	if parsed.Description != "" {
+		apiErr.Description = parsed.Description
+	}
+
+	if parsed.Parameters.RetryAfter > 0 {
+		apiErr.RetryAfter = time.Duration(parsed.Parameters.RetryAfter) * time.Second
+	}
+
+	return apiErr
+}
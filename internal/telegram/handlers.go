@@ -3,35 +3,53 @@ package telegram
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/sriram651/go-scheduler/internal/db"
+	"github.com/sriram651/go-scheduler/internal/quote"
+	"github.com/sriram651/go-scheduler/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func (c *Client) handleMessage(ctx context.Context, m *Message) {
+	if c.handleAdminCommand(ctx, m) {
+		return
+	}
+
 	switch m.Text {
 	case "/start":
 		c.handleStart(ctx, m)
 	case "/subscribe":
 		if err := c.handleSubscribe(ctx, m, true); err != nil {
-			log.Println("error handling subscribe:", err)
+			slog.ErrorContext(ctx, "Error handling subscribe", "error", err)
 		}
 	case "/unsubscribe":
 		if err := c.handleSubscribe(ctx, m, false); err != nil {
-			log.Println("error handling unsubscribe:", err)
+			slog.ErrorContext(ctx, "Error handling unsubscribe", "error", err)
 		}
 	case "/about":
 		c.handleAbout(ctx, m)
 	case "/timezone":
 		if err := c.handleTimezone(ctx, m); err != nil {
-			log.Println("error handling timezone:", err)
+			slog.ErrorContext(ctx, "Error handling timezone", "error", err)
+		}
+	case "/sendtime":
+		if err := c.handleSendTime(ctx, m); err != nil {
+			slog.ErrorContext(ctx, "Error handling sendtime", "error", err)
+		}
+	case "/category":
+		if err := c.handleCategory(ctx, m); err != nil {
+			slog.ErrorContext(ctx, "Error handling category", "error", err)
 		}
 	}
 }
@@ -45,20 +63,22 @@ func (c *Client) handleAbout(ctx context.Context, m *Message) {
 		"/subscribe — Start receiving quotes\n" +
 		"/unsubscribe — Pause anytime, no hard feelings\n" +
 		"/timezone — Set your local timezone, _no 3 AM pings_\n" +
+		"/sendtime — Pick the hour your quote arrives\n" +
+		"/category — Choose the kind of quotes you get\n" +
 		"/about — You're here!\n\n" +
 		"Built with ☕ and Go."
 
-	sendCtx, sendCancel := context.WithTimeout(ctx, 5*time.Second)
+	sendCtx, sendCancel := context.WithTimeout(ctx, c.sendTimeout)
 
 	if err := c.HandleSend(sendCtx, m.Chat.ID, about, nil); err != nil {
-		log.Println(err)
+		slog.ErrorContext(ctx, "Error sending message", "error", err)
 	}
 
 	sendCancel()
 }
 
 func (c *Client) handleStart(ctx context.Context, m *Message) {
-	log.Println("User started -", m.Chat.ID)
+	slog.InfoContext(ctx, "User started")
 
 	addNewUserErr := db.AddNewUser(ctx, c.Database, db.User{
 		ChatId:    m.Chat.ID,
@@ -67,7 +87,7 @@ func (c *Client) handleStart(ctx context.Context, m *Message) {
 	})
 
 	if addNewUserErr != nil {
-		log.Println("Error adding new user:", addNewUserErr)
+		slog.ErrorContext(ctx, "Error adding new user", "error", addNewUserErr)
 	}
 
 	welcomeMessage := "Hey there!\n\nI am *Daemon Bot*. I send a handpicked life quote once a day. If you'd love that, tap *Subscribe* below — then run /timezone so I can hit the right hour for you."
@@ -82,14 +102,14 @@ func (c *Client) handleStart(ctx context.Context, m *Message) {
 		},
 	}
 
-	sendCtx, sendCancel := context.WithTimeout(ctx, 5*time.Second)
+	sendCtx, sendCancel := context.WithTimeout(ctx, c.sendTimeout)
 
 	sendErr := c.HandleSend(sendCtx, m.Chat.ID, welcomeMessage, welcomeReplyMarkup)
 
 	sendCancel()
 
 	if sendErr != nil {
-		log.Println(sendErr)
+		slog.ErrorContext(ctx, "Error sending message", "error", sendErr)
 	}
 }
 
@@ -101,7 +121,7 @@ func (c *Client) handleSubscribe(ctx context.Context, m *Message, subscribed boo
 	})
 
 	if addNewUserErr != nil {
-		log.Println("Error adding new user:", addNewUserErr)
+		slog.ErrorContext(ctx, "Error adding new user", "error", addNewUserErr)
 
 		c.replySubscriptionChangeErr(ctx, subscribed, m.Chat.ID)
 		return addNewUserErr
@@ -112,7 +132,7 @@ func (c *Client) handleSubscribe(ctx context.Context, m *Message, subscribed boo
 		if !subscribed {
 			targetState = "unsubscribed"
 		}
-		log.Println("error updating subscription status to", targetState+":", err)
+		slog.ErrorContext(ctx, "Error updating subscription status", "target_state", targetState, "error", err)
 
 		c.replySubscriptionChangeErr(ctx, subscribed, m.Chat.ID)
 		return err
@@ -126,12 +146,14 @@ func (c *Client) handleCallback(ctx context.Context, cb *CallbackQuery) {
 	c.answerCallback(ctx, cb.ID)
 
 	if cb.Message == nil {
-		log.Println("received callback without message, id:", cb.ID)
+		slog.WarnContext(ctx, "Received callback without message", "callback_id", cb.ID)
 		return
 	}
 
 	isTimezoneContPresent := strings.HasPrefix(cb.Data, "tz-cont:")
 	isTimezonePresent := strings.HasPrefix(cb.Data, "tz:")
+	isSendHourPresent := strings.HasPrefix(cb.Data, "hour:")
+	isCategoryPresent := strings.HasPrefix(cb.Data, "cat:")
 
 	if isTimezoneContPresent {
 		continent, _ := strings.CutPrefix(cb.Data, "tz-cont:")
@@ -146,6 +168,22 @@ func (c *Client) handleCallback(ctx context.Context, cb *CallbackQuery) {
 			return
 		}
 
+		return
+	} else if isSendHourPresent {
+		sendHour, _ := strings.CutPrefix(cb.Data, "hour:")
+		if err := c.handleSendTimeSelect(ctx, sendHour, cb.Message); err != nil {
+			slog.ErrorContext(ctx, "Error handling sendtime select", "error", err)
+			return
+		}
+
+		return
+	} else if isCategoryPresent {
+		category, _ := strings.CutPrefix(cb.Data, "cat:")
+		if err := c.handleCategorySelect(ctx, category, cb.Message); err != nil {
+			slog.ErrorContext(ctx, "Error handling category select", "error", err)
+			return
+		}
+
 		return
 	}
 
@@ -170,7 +208,7 @@ func (c *Client) handleTimezone(ctx context.Context, m *Message) error {
 	})
 
 	if addNewUserErr != nil {
-		log.Println("Error adding new user:", addNewUserErr)
+		slog.ErrorContext(ctx, "Error adding new user", "error", addNewUserErr)
 
 		c.replyTimezoneUpdateErr(ctx, m.Chat.ID)
 		return addNewUserErr
@@ -186,14 +224,14 @@ func (c *Client) handleTimezone(ctx context.Context, m *Message) error {
 		},
 	}
 
-	sendCtx, sendCancel := context.WithTimeout(ctx, 5*time.Second)
+	sendCtx, sendCancel := context.WithTimeout(ctx, c.sendTimeout)
 
 	sendErr := c.HandleSend(sendCtx, m.Chat.ID, timezoneHandlerMessage, timezonesReplyMarkup)
 
 	sendCancel()
 
 	if sendErr != nil {
-		log.Println(sendErr)
+		slog.ErrorContext(ctx, "Error sending message", "error", sendErr)
 		return sendErr
 	}
 
@@ -251,14 +289,14 @@ func (c *Client) handleTimezoneContinent(ctx context.Context, cbData string, m *
 		InlineKeyboard: keyboardMarkup,
 	}
 
-	sendCtx, sendCancel := context.WithTimeout(ctx, 5*time.Second)
+	sendCtx, sendCancel := context.WithTimeout(ctx, c.sendTimeout)
 
 	sendErr := c.HandleSend(sendCtx, m.Chat.ID, timezoneContinentHandlerMessage, timezonesReplyMarkup)
 
 	sendCancel()
 
 	if sendErr != nil {
-		log.Println(sendErr)
+		slog.ErrorContext(ctx, "Error sending message", "error", sendErr)
 		return sendErr
 	}
 
@@ -279,7 +317,7 @@ func (c *Client) handleTimezoneSelect(ctx context.Context, cbData string, m *Mes
 	})
 
 	if addNewUserErr != nil {
-		log.Println("Error adding new user:", addNewUserErr)
+		slog.ErrorContext(ctx, "Error adding new user", "error", addNewUserErr)
 
 		c.replyTimezoneUpdateErr(ctx, m.Chat.ID)
 		return addNewUserErr
@@ -288,7 +326,7 @@ func (c *Client) handleTimezoneSelect(ctx context.Context, cbData string, m *Mes
 	tzUpdateErr := db.UpdateUserTimezone(ctx, c.Database, m.Chat.ID, cbData)
 
 	if tzUpdateErr != nil {
-		log.Println("Error updating user's timezone. chat_id: ", m.Chat.ID, ", timezone: ", cbData)
+		slog.ErrorContext(ctx, "Error updating user's timezone", "timezone", cbData, "error", tzUpdateErr)
 		c.replyTimezoneUpdateErr(ctx, m.Chat.ID)
 
 		return tzUpdateErr
@@ -300,21 +338,23 @@ func (c *Client) handleTimezoneSelect(ctx context.Context, cbData string, m *Mes
 }
 
 func (c *Client) replyUpdateTimezone(ctx context.Context, tz string, chatId int64) {
+	sendHour := c.userSendHour(ctx, chatId)
+
 	loc, err := time.LoadLocation(tz)
 
 	if err != nil {
-		log.Println("Error loading the tz", tz, ":", err)
+		slog.ErrorContext(ctx, "Error loading the timezone", "timezone", tz, "error", err)
 
-		answerCallbackText := "✅ *Timezone saved:* `" + tz + "`\n\nNo more 3 AM pings — quote will be sent anytime between " + strconv.Itoa(c.sendHour) + ":00hrs and " + strconv.Itoa((c.sendHour+1)%24) + ":00hrs from now on. Run /timezone again to change it."
+		answerCallbackText := "✅ *Timezone saved:* `" + tz + "`\n\nNo more 3 AM pings — quote will be sent anytime between " + strconv.Itoa(sendHour) + ":00hrs and " + strconv.Itoa((sendHour+1)%24) + ":00hrs from now on. Run /timezone again to change it."
 
-		sendCtx, sendCancel := context.WithTimeout(ctx, 5*time.Second)
+		sendCtx, sendCancel := context.WithTimeout(ctx, c.sendTimeout)
 
 		sendErr := c.HandleSend(sendCtx, chatId, answerCallbackText, nil)
 
 		sendCancel()
 
 		if sendErr != nil {
-			log.Println(sendErr)
+			slog.ErrorContext(ctx, "Error sending message", "error", sendErr)
 		}
 
 		return
@@ -335,39 +375,318 @@ func (c *Client) replyUpdateTimezone(ctx context.Context, tz string, chatId int6
 		offsetMinutesStr = "0" + offsetMinutesStr
 	}
 
-	userSendTime := strconv.Itoa(c.sendHour) + ":" + offsetMinutesStr
+	userSendTime := strconv.Itoa(sendHour) + ":" + offsetMinutesStr
 
 	answerCallbackText := "✅ *Timezone saved:* `" + tz + "`\n\nNo more 3 AM pings — quote will land at `" + userSendTime + "hrs` from now on. Run /timezone again to change it."
 
-	sendCtx, sendCancel := context.WithTimeout(ctx, 5*time.Second)
+	sendCtx, sendCancel := context.WithTimeout(ctx, c.sendTimeout)
+
+	sendErr := c.HandleSend(sendCtx, chatId, answerCallbackText, nil)
+
+	sendCancel()
+
+	if sendErr != nil {
+		slog.ErrorContext(ctx, "Error sending message", "error", sendErr)
+	}
+}
+
+func (c *Client) handleSendTime(ctx context.Context, m *Message) error {
+	addNewUserErr := db.AddNewUser(ctx, c.Database, db.User{
+		ChatId:    m.Chat.ID,
+		FirstName: m.Chat.FirstName,
+		UserName:  m.Chat.UserName,
+	})
+
+	if addNewUserErr != nil {
+		slog.ErrorContext(ctx, "Error adding new user", "error", addNewUserErr)
+
+		c.replySendTimeUpdateErr(ctx, m.Chat.ID)
+		return addNewUserErr
+	}
+
+	sendTimeHandlerMessage := "⏰ *When should your quote arrive?*\n\nPick an hour in your local time. Run /timezone first if you haven't — otherwise I'll assume UTC."
+
+	var keyboardMarkup [][]InlineKeyboardButton
+
+	// Used as buffer
+	var keyboardRow []InlineKeyboardButton
+
+	for hour := range 24 {
+		if len(keyboardRow) == 4 {
+			keyboardMarkup = append(keyboardMarkup, keyboardRow)
+
+			// Empty out the buffer
+			keyboardRow = []InlineKeyboardButton{}
+		}
+
+		hourButton := InlineKeyboardButton{
+			Text:         formatHour(hour),
+			CallbackData: "hour:" + strconv.Itoa(hour),
+		}
+
+		keyboardRow = append(keyboardRow, hourButton)
+	}
+
+	if len(keyboardRow) != 0 {
+		keyboardMarkup = append(keyboardMarkup, keyboardRow)
+	}
+
+	keyboardMarkup = append(keyboardMarkup, []InlineKeyboardButton{
+		{Text: "Use default (" + formatHour(c.SendHour()) + ")", CallbackData: "hour:default"},
+	})
+
+	sendTimeReplyMarkup := &ReplyMarkup{
+		InlineKeyboard: keyboardMarkup,
+	}
+
+	sendCtx, sendCancel := context.WithTimeout(ctx, c.sendTimeout)
+
+	sendErr := c.HandleSend(sendCtx, m.Chat.ID, sendTimeHandlerMessage, sendTimeReplyMarkup)
+
+	sendCancel()
+
+	if sendErr != nil {
+		slog.ErrorContext(ctx, "Error sending message", "error", sendErr)
+		return sendErr
+	}
+
+	return nil
+}
+
+func (c *Client) handleSendTimeSelect(ctx context.Context, cbData string, m *Message) error {
+	var sendHour sql.NullInt64
+
+	if cbData != "default" {
+		hour, convErr := strconv.Atoi(cbData)
+
+		if convErr != nil || hour < 0 || hour > 23 {
+			return fmt.Errorf("Selected send hour is not valid: %s", cbData)
+		}
+
+		sendHour = sql.NullInt64{Int64: int64(hour), Valid: true}
+	}
+
+	addNewUserErr := db.AddNewUser(ctx, c.Database, db.User{
+		ChatId:    m.Chat.ID,
+		FirstName: m.Chat.FirstName,
+		UserName:  m.Chat.UserName,
+	})
+
+	if addNewUserErr != nil {
+		slog.ErrorContext(ctx, "Error adding new user", "error", addNewUserErr)
+
+		c.replySendTimeUpdateErr(ctx, m.Chat.ID)
+		return addNewUserErr
+	}
+
+	if err := db.UpdateUserSendHour(ctx, c.Database, m.Chat.ID, sendHour); err != nil {
+		slog.ErrorContext(ctx, "Error updating user's send hour", "send_hour", cbData, "error", err)
+		c.replySendTimeUpdateErr(ctx, m.Chat.ID)
+
+		return err
+	}
+
+	var answerCallbackText string
+
+	if sendHour.Valid {
+		answerCallbackText = "✅ *Send time saved:* `" + formatHour(int(sendHour.Int64)) + "` your local time.\n\nRun /sendtime again to change it."
+	} else {
+		answerCallbackText = "✅ *Send time reset* — you'll get your quote at the default `" + formatHour(c.SendHour()) + "` your local time.\n\nRun /sendtime again to change it."
+	}
+
+	sendCtx, sendCancel := context.WithTimeout(ctx, c.sendTimeout)
+
+	sendErr := c.HandleSend(sendCtx, m.Chat.ID, answerCallbackText, nil)
+
+	sendCancel()
+
+	if sendErr != nil {
+		slog.ErrorContext(ctx, "Error sending message", "error", sendErr)
+	}
+
+	return nil
+}
+
+func (c *Client) replySendTimeUpdateErr(ctx context.Context, chatId int64) {
+	answerCallbackText := "Couldn't save your send time just now. Please try /sendtime again in a moment."
+
+	sendCtx, sendCancel := context.WithTimeout(ctx, c.sendTimeout)
+
+	sendErr := c.HandleSend(sendCtx, chatId, answerCallbackText, nil)
+
+	sendCancel()
+
+	if sendErr != nil {
+		slog.ErrorContext(ctx, "Error sending message", "error", sendErr)
+	}
+}
+
+func (c *Client) handleCategory(ctx context.Context, m *Message) error {
+	addNewUserErr := db.AddNewUser(ctx, c.Database, db.User{
+		ChatId:    m.Chat.ID,
+		FirstName: m.Chat.FirstName,
+		UserName:  m.Chat.UserName,
+	})
+
+	if addNewUserErr != nil {
+		slog.ErrorContext(ctx, "Error adding new user", "error", addNewUserErr)
+
+		c.replyCategoryUpdateErr(ctx, m.Chat.ID)
+		return addNewUserErr
+	}
+
+	categoryHandlerMessage := "🏷️ *What kind of quotes do you want?*\n\nPick a category, or _Any_ for a mix of everything:"
+
+	var keyboardMarkup [][]InlineKeyboardButton
+
+	// Used as buffer
+	var keyboardRow []InlineKeyboardButton
+
+	for _, category := range quote.Categories {
+		if len(keyboardRow) == 2 {
+			keyboardMarkup = append(keyboardMarkup, keyboardRow)
+
+			// Empty out the buffer
+			keyboardRow = []InlineKeyboardButton{}
+		}
+
+		categoryButton := InlineKeyboardButton{
+			Text:         strings.ToUpper(category[:1]) + category[1:],
+			CallbackData: "cat:" + category,
+		}
+
+		keyboardRow = append(keyboardRow, categoryButton)
+	}
+
+	if len(keyboardRow) != 0 {
+		keyboardMarkup = append(keyboardMarkup, keyboardRow)
+	}
+
+	keyboardMarkup = append(keyboardMarkup, []InlineKeyboardButton{
+		{Text: "Any", CallbackData: "cat:any"},
+	})
+
+	categoryReplyMarkup := &ReplyMarkup{
+		InlineKeyboard: keyboardMarkup,
+	}
+
+	sendCtx, sendCancel := context.WithTimeout(ctx, c.sendTimeout)
+
+	sendErr := c.HandleSend(sendCtx, m.Chat.ID, categoryHandlerMessage, categoryReplyMarkup)
+
+	sendCancel()
+
+	if sendErr != nil {
+		slog.ErrorContext(ctx, "Error sending message", "error", sendErr)
+		return sendErr
+	}
+
+	return nil
+}
+
+func (c *Client) handleCategorySelect(ctx context.Context, cbData string, m *Message) error {
+	// "any" clears the preference
+	category := ""
+
+	if cbData != "any" {
+		if !quote.IsValidCategory(cbData) {
+			return fmt.Errorf("Selected category is not valid: %s", cbData)
+		}
+
+		category = strings.ToLower(cbData)
+	}
+
+	addNewUserErr := db.AddNewUser(ctx, c.Database, db.User{
+		ChatId:    m.Chat.ID,
+		FirstName: m.Chat.FirstName,
+		UserName:  m.Chat.UserName,
+	})
+
+	if addNewUserErr != nil {
+		slog.ErrorContext(ctx, "Error adding new user", "error", addNewUserErr)
+
+		c.replyCategoryUpdateErr(ctx, m.Chat.ID)
+		return addNewUserErr
+	}
+
+	if err := db.UpdateUserCategory(ctx, c.Database, m.Chat.ID, category); err != nil {
+		slog.ErrorContext(ctx, "Error updating user's category", "category", cbData, "error", err)
+		c.replyCategoryUpdateErr(ctx, m.Chat.ID)
+
+		return err
+	}
+
+	answerCallbackText := "✅ *Category saved:* `" + category + "`\n\nYour daily quote will come from this category. Run /category again to change it."
+
+	if category == "" {
+		answerCallbackText = "✅ *Category cleared* — you'll get quotes from every category.\n\nRun /category again to change it."
+	}
+
+	sendCtx, sendCancel := context.WithTimeout(ctx, c.sendTimeout)
+
+	sendErr := c.HandleSend(sendCtx, m.Chat.ID, answerCallbackText, nil)
+
+	sendCancel()
+
+	if sendErr != nil {
+		slog.ErrorContext(ctx, "Error sending message", "error", sendErr)
+	}
+
+	return nil
+}
+
+func (c *Client) replyCategoryUpdateErr(ctx context.Context, chatId int64) {
+	answerCallbackText := "Couldn't save your category just now. Please try /category again in a moment."
+
+	sendCtx, sendCancel := context.WithTimeout(ctx, c.sendTimeout)
 
 	sendErr := c.HandleSend(sendCtx, chatId, answerCallbackText, nil)
 
 	sendCancel()
 
 	if sendErr != nil {
-		log.Println(sendErr)
+		slog.ErrorContext(ctx, "Error sending message", "error", sendErr)
 	}
 }
 
+// userSendHour returns the user's own send hour, falling back to the global one if unset or unreadable.
+func (c *Client) userSendHour(ctx context.Context, chatId int64) int {
+	sendHour, err := db.GetUserSendHour(ctx, c.Database, chatId)
+
+	if err != nil || !sendHour.Valid {
+		return c.SendHour()
+	}
+
+	return int(sendHour.Int64)
+}
+
+// Formats an hour (0-23) as "09:00"
+func formatHour(hour int) string {
+	if hour < 10 {
+		return "0" + strconv.Itoa(hour) + ":00"
+	}
+
+	return strconv.Itoa(hour) + ":00"
+}
+
 func (c *Client) replyTimezoneUpdateErr(ctx context.Context, chatId int64) {
 	answerCallbackText := "Couldn't save your timezone just now. Please try /timezone again in a moment."
 
-	sendCtx, sendCancel := context.WithTimeout(ctx, 5*time.Second)
+	sendCtx, sendCancel := context.WithTimeout(ctx, c.sendTimeout)
 
 	sendErr := c.HandleSend(sendCtx, chatId, answerCallbackText, nil)
 
 	sendCancel()
 
 	if sendErr != nil {
-		log.Println(sendErr)
+		slog.ErrorContext(ctx, "Error sending message", "error", sendErr)
 	}
 }
 
 func (c *Client) answerCallback(ctx context.Context, cbId string) {
 	answerCallbackEndpoint := c.endpoint("/answerCallbackQuery", "")
 
-	callbackContext, callbackCancel := context.WithTimeout(ctx, 5*time.Second)
+	callbackContext, callbackCancel := context.WithTimeout(ctx, c.sendTimeout)
 
 	defer callbackCancel()
 
@@ -382,7 +701,12 @@ func (c *Client) answerCallback(ctx context.Context, cbId string) {
 	answerCallbackBodyJson, marshalErr := json.Marshal(answerCallbackBody)
 
 	if marshalErr != nil {
-		log.Println(marshalErr)
+		slog.ErrorContext(ctx, "Error encoding answerCallbackQuery", "error", marshalErr)
+		return
+	}
+
+	if err := c.limiter.Wait(callbackContext, 0); err != nil {
+		slog.ErrorContext(ctx, "Error waiting on the rate limiter", "error", err)
 		return
 	}
 
@@ -391,21 +715,20 @@ func (c *Client) answerCallback(ctx context.Context, cbId string) {
 	answerCallbackReq, answerCallbackReqErr := http.NewRequestWithContext(callbackContext, http.MethodPost, answerCallbackEndpoint, requestBody)
 
 	if answerCallbackReqErr != nil {
-		log.Println(answerCallbackReqErr)
+		slog.ErrorContext(ctx, "Error building answerCallbackQuery request", "error", answerCallbackReqErr)
 		return
 	}
 
 	res, answerCallbackResErr := c.client.Do(answerCallbackReq)
 
 	if answerCallbackResErr != nil {
-		log.Println(answerCallbackResErr)
+		slog.ErrorContext(ctx, "Error calling answerCallbackQuery", "error", answerCallbackResErr)
 		return
 	}
 
 	if res.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(res.Body)
-		log.Println("[answerCallback] Callback ID:", cbId)
-		log.Println("[answerCallback] Non-200 status code with body:", string(body))
+		slog.ErrorContext(ctx, "answerCallbackQuery returned non-200", "callback_id", cbId, "status", res.StatusCode, "body", string(body))
 	}
 
 	defer res.Body.Close()
@@ -420,14 +743,14 @@ func (c *Client) replySubscription(ctx context.Context, subscribed bool, chatId
 		answerCallbackText = "Unsubscribed. No hard feelings — /subscribe again anytime."
 	}
 
-	sendCtx, sendCancel := context.WithTimeout(ctx, 5*time.Second)
+	sendCtx, sendCancel := context.WithTimeout(ctx, c.sendTimeout)
 
 	sendErr := c.HandleSend(sendCtx, chatId, answerCallbackText, nil)
 
 	sendCancel()
 
 	if sendErr != nil {
-		log.Println(sendErr)
+		slog.ErrorContext(ctx, "Error sending message", "error", sendErr)
 	}
 }
 
@@ -440,18 +763,29 @@ func (c *Client) replySubscriptionChangeErr(ctx context.Context, subscribed bool
 		answerCallbackText = "Couldn't unsubscribe you right now. Please try again in a moment."
 	}
 
-	sendCtx, sendCancel := context.WithTimeout(ctx, 5*time.Second)
+	sendCtx, sendCancel := context.WithTimeout(ctx, c.sendTimeout)
 
 	sendErr := c.HandleSend(sendCtx, chatId, answerCallbackText, nil)
 
 	sendCancel()
 
 	if sendErr != nil {
-		log.Println(sendErr)
+		slog.ErrorContext(ctx, "Error sending message", "error", sendErr)
 	}
 }
 
+// HandleSend sends a message through the rate limiter, waiting out Telegram's retry_after and retrying on 429.
 func (c *Client) HandleSend(ctx context.Context, chatId int64, text string, replyMarkup *ReplyMarkup) error {
+	ctx, span := tracer.Start(ctx, "telegram.HandleSend", trace.WithAttributes(attribute.Int64("chat_id", chatId)))
+
+	err := c.handleSend(ctx, chatId, text, replyMarkup)
+
+	tracing.End(span, err)
+
+	return err
+}
+
+func (c *Client) handleSend(ctx context.Context, chatId int64, text string, replyMarkup *ReplyMarkup) error {
 	message := SendMessage{
 		ChatID:      chatId,
 		Text:        text,
@@ -465,6 +799,35 @@ func (c *Client) HandleSend(ctx context.Context, chatId int64, text string, repl
 		return marshalErr
 	}
 
+	for attempt := 1; ; attempt++ {
+		if err := c.limiter.Wait(ctx, chatId); err != nil {
+			return err
+		}
+
+		sendErr := c.sendMessage(ctx, messageJson)
+
+		var apiErr *APIError
+
+		if !errors.As(sendErr, &apiErr) || apiErr.RetryAfter == 0 || attempt == maxRateLimitAttempts {
+			return sendErr
+		}
+
+		c.limiter.Pause(chatId, apiErr.RetryAfter)
+
+		// Flood waits often outlast a send timeout. Sleeping until the deadline would only swap the 429 for a bare
+		// context.DeadlineExceeded, so hand it back and let the caller wait out RetryAfter.
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < apiErr.RetryAfter {
+			return sendErr
+		}
+
+		trace.SpanFromContext(ctx).AddEvent("rate_limited", trace.WithAttributes(attribute.Int("attempt", attempt)))
+
+		slog.WarnContext(ctx, "Rate limited, retrying", "retry_after", apiErr.RetryAfter.String(), "attempt", attempt, "max_attempts", maxRateLimitAttempts)
+	}
+}
+
+// sendMessage makes a single sendMessage call. Non-200 responses come back as *APIError.
+func (c *Client) sendMessage(ctx context.Context, messageJson []byte) error {
 	sendMessageBody := bytes.NewBuffer(messageJson)
 
 	httpRequest, requestErr := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint("/sendMessage", ""), sendMessageBody)
@@ -485,7 +848,7 @@ func (c *Client) HandleSend(ctx context.Context, chatId int64, text string, repl
 
 	if response.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(response.Body)
-		return fmt.Errorf("upstream error sending message %d: %s", response.StatusCode, strings.TrimSpace(string(body)))
+		return newAPIError(response.StatusCode, body)
 	}
 
 	var raw struct {
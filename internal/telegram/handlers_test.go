@@ -0,0 +1,149 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sriram651/go-scheduler/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+const sentMessageBody = `{"ok":true,"result":{"message_id":1,"chat":{"id":42},"text":"hi"}}`
+
+func TestHandleSendSpan(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	shutdown := tracing.SetupWithExporter(exporter)
+
+	t.Cleanup(func() { shutdown(context.Background()) })
+
+	tests := []struct {
+		name string
+		// Responses served in order, the last one repeated
+		responses  []string
+		statuses   []int
+		timeout    time.Duration
+		wantErr    bool
+		wantStatus codes.Code
+		wantEvents int
+		wantCalls  int
+	}{
+		{
+			name:       "sent",
+			responses:  []string{sentMessageBody},
+			statuses:   []int{http.StatusOK},
+			timeout:    5 * time.Second,
+			wantStatus: codes.Unset,
+			wantCalls:  1,
+		},
+		{
+			name:       "blocked",
+			responses:  []string{`{"ok":false,"error_code":403,"description":"Forbidden: bot was blocked by the user"}`},
+			statuses:   []int{http.StatusForbidden},
+			timeout:    5 * time.Second,
+			wantErr:    true,
+			wantStatus: codes.Error,
+			wantCalls:  1,
+		},
+		{
+			name: "rate limited then sent",
+			responses: []string{
+				`{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 1","parameters":{"retry_after":1}}`,
+				sentMessageBody,
+			},
+			statuses:   []int{http.StatusTooManyRequests, http.StatusOK},
+			timeout:    5 * time.Second,
+			wantStatus: codes.Unset,
+			wantEvents: 1,
+			wantCalls:  2,
+		},
+		{
+			name:       "rate limited past the deadline",
+			responses:  []string{`{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 30","parameters":{"retry_after":30}}`},
+			statuses:   []int{http.StatusTooManyRequests},
+			timeout:    time.Second,
+			wantErr:    true,
+			wantStatus: codes.Error,
+			wantCalls:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter.Reset()
+
+			var requests atomic.Int64
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				i := min(int(requests.Add(1))-1, len(tt.responses)-1)
+
+				w.WriteHeader(tt.statuses[i])
+				w.Write([]byte(tt.responses[i]))
+			}))
+
+			defer server.Close()
+
+			c := NewClient(server.URL+"/bot", "token", time.Second, tt.timeout, nil)
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+
+			err := c.HandleSend(ctx, 42, "hi", nil)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("HandleSend error = %v, want error: %v", err, tt.wantErr)
+			}
+
+			var apiErr *APIError
+
+			if tt.wantErr && !errors.As(err, &apiErr) {
+				t.Errorf("HandleSend error = %v, want an *APIError", err)
+			}
+
+			if got := int(requests.Load()); got != tt.wantCalls {
+				t.Errorf("sendMessage calls = %d, want %d", got, tt.wantCalls)
+			}
+
+			spans := exporter.GetSpans()
+
+			if len(spans) != 1 {
+				t.Fatalf("got %d spans, want 1", len(spans))
+			}
+
+			span := spans[0]
+
+			if span.Name != "telegram.HandleSend" {
+				t.Errorf("span name = %q, want telegram.HandleSend", span.Name)
+			}
+
+			wantAttr := attribute.Int64("chat_id", 42)
+
+			if len(span.Attributes) != 1 || span.Attributes[0] != wantAttr {
+				t.Errorf("span attributes = %v, want [%v]", span.Attributes, wantAttr)
+			}
+
+			if span.Status.Code != tt.wantStatus {
+				t.Errorf("span status = %v, want %v", span.Status.Code, tt.wantStatus)
+			}
+
+			// Failed spans also carry the "exception" event from RecordError
+			var rateLimited int
+
+			for _, event := range span.Events {
+				if event.Name == "rate_limited" {
+					rateLimited++
+				}
+			}
+
+			if rateLimited != tt.wantEvents {
+				t.Errorf("span events = %v, want %d rate_limited events", span.Events, tt.wantEvents)
+			}
+		})
+	}
+}
@@ -4,15 +4,24 @@ import (
 	"context"
 	"encoding/json"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/sriram651/go-scheduler/internal/db"
+	"github.com/sriram651/go-scheduler/internal/logging"
+	"github.com/sriram651/go-scheduler/internal/metrics"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func (c *Client) StartPolling(ctx context.Context) {
+	// A webhook left behind by webhook mode would make every getUpdates fail with 409
+	if err := c.DeleteWebhook(ctx); err != nil {
+		slog.ErrorContext(ctx, "Error removing the webhook before polling", "error", err)
+	}
+
 	// Pure polling logic only
 	for {
 		// Check if the Outer-context is cancelled to stop running the forever-polling
@@ -28,7 +37,7 @@ func (c *Client) StartPolling(ctx context.Context) {
 				c.UpdateOffset(newOffset)
 
 				if err := db.UpdateBotConfig(ctx, c.Database, "telegram_offset", newOffset); err != nil {
-					log.Println("Error updating the telegram_offset to db:", err)
+					slog.ErrorContext(ctx, "Error updating the telegram_offset to db", "error", err)
 				}
 			}
 		}
@@ -36,32 +45,43 @@ func (c *Client) StartPolling(ctx context.Context) {
 }
 
 func (c *Client) getUpdates(parentCtx context.Context) []Update {
-	params := "?offset=" + strconv.Itoa(int(c.offset))
+	// Telegram holds the request open for up to timeout seconds while there are no updates
+	params := "?offset=" + strconv.Itoa(int(c.offset)) + "&timeout=" + strconv.Itoa(int(c.pollTimeout.Seconds()))
 	getUpdatesEndpoint := c.endpoint("/getUpdates", params)
 
-	ctx, cancel := context.WithTimeout(parentCtx, 65*time.Second)
+	ctx, cancel := context.WithTimeout(parentCtx, c.pollTimeout+pollRequestSlack)
 
 	defer cancel()
 
+	defer func() { c.lastPollReturn.Store(time.Now().UnixNano()) }()
+
 	req, reqErr := http.NewRequestWithContext(ctx, http.MethodGet, getUpdatesEndpoint, nil)
 
 	if reqErr != nil {
-		log.Println(reqErr)
+		slog.ErrorContext(parentCtx, "Error building getUpdates request", "error", reqErr)
 		return nil
 	}
 
 	res, resErr := c.client.Do(req)
 
 	if resErr != nil {
-		log.Println(resErr)
+		slog.ErrorContext(parentCtx, "Error calling getUpdates", "error", resErr)
+
+		// Shutting down isn't a poll failure
+		if parentCtx.Err() == nil {
+			metrics.PollErrors.Inc()
+		}
+
 		return nil
 	}
 
 	if res.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(res.Body)
-		log.Println("Received status code:", res.StatusCode, " with body:", body)
+		slog.ErrorContext(parentCtx, "getUpdates returned non-200", "status", res.StatusCode, "body", string(body))
 		defer res.Body.Close()
 
+		metrics.PollErrors.Inc()
+
 		return nil
 	}
 
@@ -74,15 +94,32 @@ func (c *Client) getUpdates(parentCtx context.Context) []Update {
 	res.Body.Close()
 
 	if decodeErr != nil {
-		log.Println(decodeErr)
+		slog.ErrorContext(parentCtx, "Error decoding getUpdates response", "error", decodeErr)
+
+		metrics.PollErrors.Inc()
 
 		return nil
 	}
 
+	c.lastPollSuccess.Store(time.Now().UnixNano())
+
 	return updatesRes.Result
 }
 
 func (c *Client) routeUpdate(ctx context.Context, u Update) {
+	defer metrics.UpdatesProcessed.Inc()
+
+	ctx, span := tracer.Start(ctx, "telegram.routeUpdate", trace.WithAttributes(attribute.Int("update_id", u.UpdateID)))
+	defer span.End()
+
+	// Every log line written while handling the update carries its update and chat IDs
+	ctx = logging.With(ctx, "update_id", u.UpdateID)
+
+	if chatID, ok := u.chatID(); ok {
+		ctx = logging.With(ctx, "chat_id", chatID)
+		span.SetAttributes(attribute.Int64("chat_id", chatID))
+	}
+
 	if u.Message != nil {
 		c.handleMessage(ctx, u.Message)
 	} else if u.CallbackQuery != nil {
@@ -0,0 +1,212 @@
+package telegram
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Telegram allows roughly 30 messages per second across all chats and about 1 per second within a single chat.
+const (
+	globalRatePerSecond  = 30
+	globalBurst          = 30
+	perChatRatePerSecond = 1
+	perChatBurst         = 3
+
+	// Idle per-chat buckets are dropped once the map grows past this size
+	maxChatBuckets = 10000
+
+	// A 429 only holds back its own chat, unless this many chats are paused at once: then it's the bot-wide flood limit
+	globalFloodChats = 3
+)
+
+type tokenBucket struct {
+	capacity float64
+	tokens   float64
+	rate     float64
+	last     time.Time
+
+	// Set by Pause on a chat's bucket after a 429 for that chat
+	pausedUntil time.Time
+}
+
+func newTokenBucket(rate float64, capacity float64, now time.Time) *tokenBucket {
+	return &tokenBucket{
+		capacity: capacity,
+		tokens:   capacity,
+		rate:     rate,
+		last:     now,
+	}
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	elapsed := now.Sub(b.last).Seconds()
+
+	if elapsed > 0 {
+		b.tokens = min(b.capacity, b.tokens+elapsed*b.rate)
+		b.last = now
+	}
+}
+
+// reserve takes one token, going into debt if the bucket is empty, and returns how long the caller must wait before using it.
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.refill(now)
+
+	b.tokens--
+
+	if b.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// refund gives back a token taken by reserve but never used.
+func (b *tokenBucket) refund() {
+	b.tokens = min(b.capacity, b.tokens+1)
+}
+
+type rateLimiter struct {
+	mutex       sync.Mutex
+	global      *tokenBucket
+	chats       map[int64]*tokenBucket
+	pausedUntil time.Time
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{
+		global: newTokenBucket(globalRatePerSecond, globalBurst, time.Now()),
+		chats:  make(map[int64]*tokenBucket),
+	}
+}
+
+// Wait blocks until both the global and the per-chat bucket allow another message to chatID.
+// A chatID of 0 only consults the global bucket (used for non-message calls like answerCallbackQuery).
+// If a Pause on chatID or the whole bot lasts past ctx's deadline it returns a 429 *APIError straight away, without
+// taking any tokens, so the caller can wait out RetryAfter itself. Tokens taken by a wait that ctx cuts short are given back.
+func (l *rateLimiter) Wait(ctx context.Context, chatID int64) error {
+	l.mutex.Lock()
+
+	now := time.Now()
+
+	pausedUntil := l.pausedUntil
+
+	if chatBucket, ok := l.chats[chatID]; ok && chatBucket.pausedUntil.After(pausedUntil) {
+		pausedUntil = chatBucket.pausedUntil
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && pausedUntil.After(deadline) {
+		retryAfter := pausedUntil.Sub(now)
+
+		l.mutex.Unlock()
+
+		return &APIError{
+			StatusCode:  http.StatusTooManyRequests,
+			Description: "Too Many Requests: still waiting out an earlier retry_after",
+			RetryAfter:  retryAfter,
+		}
+	}
+
+	delay := l.global.reserve(now)
+
+	if chatID != 0 {
+		chatBucket, ok := l.chats[chatID]
+
+		if !ok {
+			if len(l.chats) >= maxChatBuckets {
+				l.pruneIdle(now)
+			}
+
+			chatBucket = newTokenBucket(perChatRatePerSecond, perChatBurst, now)
+			l.chats[chatID] = chatBucket
+		}
+
+		delay = max(delay, chatBucket.reserve(now))
+	}
+
+	if pause := pausedUntil.Sub(now); pause > delay {
+		delay = pause
+	}
+
+	l.mutex.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		// Otherwise the debt slows down every send after it
+		l.refund(chatID)
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (l *rateLimiter) refund(chatID int64) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.global.refund()
+
+	if chatBucket, ok := l.chats[chatID]; ok {
+		chatBucket.refund()
+	}
+}
+
+// Pause holds back sends to chatID until d has passed, used when Telegram answers with 429 retry_after.
+// Telegram's per-chat limit only concerns that chat, so the rest of a broadcast carries on. Once globalFloodChats
+// chats are paused at the same time the bot as a whole is over the limit, and every caller is held back instead.
+// A chatID of 0 (calls not tied to a chat) always pauses everyone.
+func (l *rateLimiter) Pause(chatID int64, d time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := time.Now()
+	until := now.Add(d)
+
+	if chatID != 0 {
+		chatBucket, ok := l.chats[chatID]
+
+		if !ok {
+			chatBucket = newTokenBucket(perChatRatePerSecond, perChatBurst, now)
+			l.chats[chatID] = chatBucket
+		}
+
+		if until.After(chatBucket.pausedUntil) {
+			chatBucket.pausedUntil = until
+		}
+
+		var pausedChats int
+
+		for _, bucket := range l.chats {
+			if bucket.pausedUntil.After(now) {
+				pausedChats++
+			}
+		}
+
+		if pausedChats < globalFloodChats {
+			return
+		}
+	}
+
+	if until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+}
+
+// Must be called with the mutex held
+func (l *rateLimiter) pruneIdle(now time.Time) {
+	for chatID, bucket := range l.chats {
+		bucket.refill(now)
+
+		if bucket.tokens >= bucket.capacity && !bucket.pausedUntil.After(now) {
+			delete(l.chats, chatID)
+		}
+	}
+}
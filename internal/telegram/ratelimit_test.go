@@ -0,0 +1,69 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterPause(t *testing.T) {
+	l := newRateLimiter()
+
+	l.Pause(1, time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	var apiErr *APIError
+
+	if err := l.Wait(ctx, 1); !errors.As(err, &apiErr) || apiErr.RetryAfter <= 0 {
+		t.Errorf("Wait on the paused chat = %v, want a 429 *APIError", err)
+	}
+
+	// Other chats, and calls not tied to a chat, aren't held back by one chat's 429
+	if err := l.Wait(ctx, 2); err != nil {
+		t.Errorf("Wait on another chat = %v, want nil", err)
+	}
+
+	if err := l.Wait(ctx, 0); err != nil {
+		t.Errorf("Wait without a chat = %v, want nil", err)
+	}
+
+	// Enough chats rate limited at once is the bot-wide flood limit
+	for chatID := int64(2); chatID <= globalFloodChats; chatID++ {
+		l.Pause(chatID, time.Minute)
+	}
+
+	if err := l.Wait(ctx, 100); !errors.As(err, &apiErr) {
+		t.Errorf("Wait during a bot-wide flood wait = %v, want a 429 *APIError", err)
+	}
+}
+
+func TestRateLimiterRefundsCancelledWaits(t *testing.T) {
+	l := newRateLimiter()
+
+	// Use up the chat's burst, so the next Wait has to queue
+	for range perChatBurst {
+		if err := l.Wait(context.Background(), 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tokensBefore := l.chats[1].tokens
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := l.Wait(ctx, 1); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Wait with a cancelled ctx = %v, want context.Canceled", err)
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	// Refilled a little since, but never left a token in debt
+	if tokens := l.chats[1].tokens; tokens < tokensBefore {
+		t.Errorf("chat tokens after a cancelled Wait = %.3f, want at least %.3f", tokens, tokensBefore)
+	}
+}
@@ -21,6 +21,19 @@ type Update struct {
 	CallbackQuery *CallbackQuery `json:"callback_query"`
 }
 
+// chatID is the chat the update came from, for messages and for callbacks on a message.
+func (u Update) chatID() (int64, bool) {
+	if u.Message != nil {
+		return u.Message.Chat.ID, true
+	}
+
+	if u.CallbackQuery != nil && u.CallbackQuery.Message != nil {
+		return u.CallbackQuery.Message.Chat.ID, true
+	}
+
+	return 0, false
+}
+
 type GetUpdatesResponse struct {
 	Ok     bool     `json:"ok"`
 	Result []Update `json:"result"`
@@ -0,0 +1,219 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Largest update body the webhook will read
+const maxWebhookBodyBytes = 1 << 20
+
+// Backoff between failed setWebhook calls
+const (
+	webhookRetryBaseDelay = 5 * time.Second
+	webhookRetryMaxDelay  = time.Minute
+)
+
+// ServeWebhook serves Telegram updates on listenAddr, at publicURL's path, until ctx is cancelled. It runs on every
+// instance, since the proxy in front may route an update to any of them: while this instance leads (see Lead) updates
+// are handled here, otherwise the request goes to standby, which should hand it on to the leader.
+// Requests without the matching X-Telegram-Bot-Api-Secret-Token header are rejected.
+func (c *Client) ServeWebhook(ctx context.Context, listenAddr string, publicURL string, secretToken string, standby http.HandlerFunc) {
+	// Without a secret anyone who finds the URL could inject updates
+	if secretToken == "" {
+		slog.ErrorContext(ctx, "Webhook mode needs TG_WEBHOOK_SECRET, not starting the webhook server")
+		return
+	}
+
+	parsedURL, parseErr := url.Parse(publicURL)
+
+	if parseErr != nil {
+		slog.ErrorContext(ctx, "Invalid webhook URL", "error", parseErr)
+		return
+	}
+
+	webhookPath := parsedURL.Path
+
+	if webhookPath == "" {
+		webhookPath = "/"
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+webhookPath, func(w http.ResponseWriter, r *http.Request) {
+		c.handleWebhook(secretToken, standby, w, r)
+	})
+
+	server := &http.Server{
+		Addr:              listenAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	serverErr := make(chan error, 1)
+
+	go func() {
+		slog.InfoContext(ctx, "Webhook server listening", "addr", listenAddr)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case <-ctx.Done():
+	case err := <-serverErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			slog.ErrorContext(ctx, "Webhook server stopped", "error", err)
+		}
+	}
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer shutdownCancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.ErrorContext(ctx, "Error shutting down the webhook server", "error", err)
+	}
+
+	slog.InfoContext(ctx, "Webhook server shutting down")
+}
+
+// Lead has webhook updates handled on ctx, the leader term's context, until StepDown.
+func (c *Client) Lead(ctx context.Context) {
+	c.leaderMutex.Lock()
+	defer c.leaderMutex.Unlock()
+
+	c.leaderCtx = ctx
+}
+
+// StepDown hands webhook updates back to the standby handler, and waits for the ones being handled to finish.
+func (c *Client) StepDown() {
+	c.leaderMutex.Lock()
+	defer c.leaderMutex.Unlock()
+
+	c.leaderCtx = nil
+}
+
+func (c *Client) handleWebhook(secretToken string, standby http.HandlerFunc, w http.ResponseWriter, r *http.Request) {
+	receivedToken := r.Header.Get("X-Telegram-Bot-Api-Secret-Token")
+
+	if subtle.ConstantTimeCompare([]byte(receivedToken), []byte(secretToken)) != 1 {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	// Held for the whole update, so StepDown waits for it
+	c.leaderMutex.RLock()
+	defer c.leaderMutex.RUnlock()
+
+	// Handled on the leader term's context rather than the request's, so a dropped connection doesn't abort a half-done handler
+	ctx := c.leaderCtx
+
+	if ctx == nil {
+		standby(w, r)
+		return
+	}
+
+	var u Update
+
+	decodeErr := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxWebhookBodyBytes)).Decode(&u)
+
+	if decodeErr != nil {
+		slog.ErrorContext(ctx, "Error decoding webhook update", "error", decodeErr)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	c.routeUpdate(ctx, u)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// RegisterWebhook registers publicURL with Telegram via setWebhook. Until that works no updates arrive at all,
+// so failures are retried with backoff until it succeeds or ctx is cancelled.
+// The webhook stays registered once the term ends, since a new leader may already have set its own; see DeleteWebhook.
+func (c *Client) RegisterWebhook(ctx context.Context, publicURL string, secretToken string) {
+	delay := webhookRetryBaseDelay
+
+	for {
+		err := c.setWebhook(ctx, publicURL, secretToken)
+
+		if err == nil {
+			slog.InfoContext(ctx, "Webhook registered")
+			return
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		slog.ErrorContext(ctx, "Error registering the webhook, retrying", "error", err, "delay", delay.String())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		delay = min(delay*2, webhookRetryMaxDelay)
+	}
+}
+
+func (c *Client) setWebhook(ctx context.Context, publicURL string, secretToken string) error {
+	type SetWebhookBody struct {
+		URL            string   `json:"url"`
+		SecretToken    string   `json:"secret_token,omitempty"`
+		AllowedUpdates []string `json:"allowed_updates"`
+	}
+
+	return c.postMethod(ctx, "/setWebhook", SetWebhookBody{
+		URL:            publicURL,
+		SecretToken:    secretToken,
+		AllowedUpdates: []string{"message", "callback_query"},
+	})
+}
+
+// DeleteWebhook removes the registered webhook. Polling calls it before starting, since getUpdates returns 409
+// while a webhook is set.
+func (c *Client) DeleteWebhook(ctx context.Context) error {
+	return c.postMethod(ctx, "/deleteWebhook", struct{}{})
+}
+
+func (c *Client) postMethod(ctx context.Context, path string, body any) error {
+	bodyJson, marshalErr := json.Marshal(body)
+
+	if marshalErr != nil {
+		return marshalErr
+	}
+
+	methodCtx, methodCancel := context.WithTimeout(ctx, c.sendTimeout)
+	defer methodCancel()
+
+	httpRequest, requestErr := http.NewRequestWithContext(methodCtx, http.MethodPost, c.endpoint(path, ""), bytes.NewBuffer(bodyJson))
+
+	if requestErr != nil {
+		return requestErr
+	}
+
+	httpRequest.Header.Set("Content-Type", "application/json")
+
+	response, responseErr := c.client.Do(httpRequest)
+
+	if responseErr != nil {
+		return responseErr
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		responseBody, _ := io.ReadAll(response.Body)
+		return fmt.Errorf("%s failed: %w", path, newAPIError(response.StatusCode, responseBody))
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package telegram
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWebhookHandsUpdatesToStandbyUnlessLeading(t *testing.T) {
+	c := NewClient("http://telegram.invalid/bot", "token", time.Second, time.Second, nil)
+
+	var standbyCalls int
+
+	standby := func(w http.ResponseWriter, r *http.Request) {
+		standbyCalls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	post := func(secret string) int {
+		r := httptest.NewRequest(http.MethodPost, "/telegram/webhook", strings.NewReader(`{"update_id":1}`))
+		r.Header.Set("X-Telegram-Bot-Api-Secret-Token", secret)
+
+		w := httptest.NewRecorder()
+
+		c.handleWebhook("secret", standby, w, r)
+
+		return w.Code
+	}
+
+	if code := post("wrong"); code != http.StatusUnauthorized || standbyCalls != 0 {
+		t.Errorf("wrong secret: status %d, %d standby calls, want 401 and none", code, standbyCalls)
+	}
+
+	if code := post("secret"); code != http.StatusServiceUnavailable || standbyCalls != 1 {
+		t.Errorf("on standby: status %d, %d standby calls, want 503 and 1", code, standbyCalls)
+	}
+
+	c.Lead(context.Background())
+
+	if code := post("secret"); code != http.StatusOK || standbyCalls != 1 {
+		t.Errorf("leading: status %d, %d standby calls, want 200 and still 1", code, standbyCalls)
+	}
+
+	c.StepDown()
+
+	if code := post("secret"); code != http.StatusServiceUnavailable || standbyCalls != 2 {
+		t.Errorf("after StepDown: status %d, %d standby calls, want 503 and 2", code, standbyCalls)
+	}
+}
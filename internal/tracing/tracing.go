@@ -0,0 +1,74 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const serviceName = "go-scheduler"
+
+// Setup installs an OTLP/HTTP trace exporter as the global tracer provider. The endpoint, headers and so on come
+// from the standard OTEL_EXPORTER_OTLP_* env vars. With enabled false nothing is installed and every span is a no-op.
+// The returned shutdown flushes buffered spans and must be called before exit.
+func Setup(ctx context.Context, enabled bool) (shutdown func(context.Context) error, err error) {
+	if !enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+
+	if err != nil {
+		return nil, fmt.Errorf("creating OTLP exporter: %w", err)
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(attribute.String("service.name", serviceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("building trace resource: %w", err)
+	}
+
+	return install(sdktrace.WithBatcher(exporter), res), nil
+}
+
+// SetupWithExporter installs exporter synchronously, so spans are visible as soon as they end.
+// Meant for tests, e.g. with tracetest.NewInMemoryExporter.
+func SetupWithExporter(exporter sdktrace.SpanExporter) (shutdown func(context.Context) error) {
+	return install(sdktrace.WithSyncer(exporter), resource.Default())
+}
+
+func install(exporterOption sdktrace.TracerProviderOption, res *resource.Resource) func(context.Context) error {
+	provider := sdktrace.NewTracerProvider(exporterOption, sdktrace.WithResource(res))
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return provider.Shutdown
+}
+
+// Tracer returns the tracer for a package, named after its import path.
+func Tracer(pkg string) trace.Tracer {
+	return otel.Tracer("github.com/sriram651/go-scheduler/internal/" + pkg)
+}
+
+// End records err on span, if any, and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}